@@ -0,0 +1,615 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+const controlsFile = "controls.json"
+
+// action is a function of the game which may be bound to any number of
+// keys, mouse buttons, gamepad buttons and gamepad axes.
+type action int
+
+const (
+	actionMoveX action = iota
+	actionMoveY
+	actionAimX
+	actionAimY
+	actionFire
+	actionWalk
+	actionPause
+	actionJoin
+	actionBack
+	actionSelect
+	actionMenuUp
+	actionMenuDown
+	actionMenuLeft
+	actionMenuRight
+	actionPlayAgain
+	actionContinue
+	actionMute
+	actionQuickSave
+	actionQuickLoad
+	actionZoomIn
+	actionZoomOut
+	actionCheatFullbright
+	actionCheatGodMode
+	actionCheatNoclipMode
+	actionCheatDebugMode
+	actionCheatProfile
+	actionCheatSlowDown
+	actionCheatSpeedUp
+	actionCheatSpawnVampires
+	actionCheatSpawnBats
+	actionCheatSpawnGhosts
+	actionCheatIncreaseHealth
+	actionCheatSkipSouls
+	actionCheatNextLevel
+	actionCheatWin
+	numActions
+)
+
+// actionNames are the names of actions as saved in the controls file.
+var actionNames = map[action]string{
+	actionMoveX:               "MoveX",
+	actionMoveY:               "MoveY",
+	actionAimX:                "AimX",
+	actionAimY:                "AimY",
+	actionFire:                "Fire",
+	actionWalk:                "Walk",
+	actionPause:               "Pause",
+	actionJoin:                "Join",
+	actionBack:                "Back",
+	actionSelect:              "Select",
+	actionMenuUp:              "MenuUp",
+	actionMenuDown:            "MenuDown",
+	actionMenuLeft:            "MenuLeft",
+	actionMenuRight:           "MenuRight",
+	actionPlayAgain:           "PlayAgain",
+	actionContinue:            "Continue",
+	actionMute:                "Mute",
+	actionQuickSave:           "QuickSave",
+	actionQuickLoad:           "QuickLoad",
+	actionZoomIn:              "ZoomIn",
+	actionZoomOut:             "ZoomOut",
+	actionCheatFullbright:     "CheatFullbright",
+	actionCheatGodMode:        "CheatGodMode",
+	actionCheatNoclipMode:     "CheatNoclipMode",
+	actionCheatDebugMode:      "CheatDebugMode",
+	actionCheatProfile:        "CheatProfile",
+	actionCheatSlowDown:       "CheatSlowDown",
+	actionCheatSpeedUp:        "CheatSpeedUp",
+	actionCheatSpawnVampires:  "CheatSpawnVampires",
+	actionCheatSpawnBats:      "CheatSpawnBats",
+	actionCheatSpawnGhosts:    "CheatSpawnGhosts",
+	actionCheatIncreaseHealth: "CheatIncreaseHealth",
+	actionCheatSkipSouls:      "CheatSkipSouls",
+	actionCheatNextLevel:      "CheatNextLevel",
+	actionCheatWin:            "CheatWin",
+}
+
+// defaultBindings are the bindings of each action when they have not been
+// changed. Axis actions are bound using a sign, which is the direction a key
+// or button moves the axis in, or whether a gamepad axis is inverted.
+//
+// Actions which are read in the same state of the game, such as while playing
+// or while a menu is open, do not share a default binding. Menus are closed
+// by both the back and pause actions, so Escape is bound to pause only.
+var defaultBindings = map[action][]string{
+	actionMoveX:               {"-A", "-ArrowLeft", "+D", "+ArrowRight", "+GamepadLeftStickHorizontal"},
+	actionMoveY:               {"-W", "-ArrowUp", "+S", "+ArrowDown", "+GamepadLeftStickVertical"},
+	actionAimX:                {"+GamepadRightStickHorizontal"},
+	actionAimY:                {"+GamepadRightStickVertical"},
+	actionFire:                {"MouseLeft"},
+	actionWalk:                {"Shift"},
+	actionPause:               {"Escape", "GamepadCenterRight"},
+	actionJoin:                {"Enter", "GamepadRightBottom"},
+	actionBack:                {"Backspace", "GamepadRightRight"},
+	actionSelect:              {"Enter", "Space", "GamepadRightBottom"},
+	actionMenuUp:              {"W", "ArrowUp", "GamepadLeftTop", "-GamepadLeftStickVertical"},
+	actionMenuDown:            {"S", "ArrowDown", "GamepadLeftBottom", "+GamepadLeftStickVertical"},
+	actionMenuLeft:            {"A", "ArrowLeft", "GamepadLeftLeft", "-GamepadLeftStickHorizontal"},
+	actionMenuRight:           {"D", "ArrowRight", "GamepadLeftRight", "+GamepadLeftStickHorizontal"},
+	actionPlayAgain:           {"Enter", "GamepadRightBottom"},
+	actionContinue:            {"C", "GamepadRightTop"},
+	actionMute:                {"M"},
+	actionQuickSave:           {"F5"},
+	actionQuickLoad:           {"F9"},
+	actionZoomIn:              {"E", "PageUp"},
+	actionZoomOut:             {"Q", "PageDown"},
+	actionCheatFullbright:     {"Control+F"},
+	actionCheatGodMode:        {"Control+G"},
+	actionCheatNoclipMode:     {"Control+N"},
+	actionCheatDebugMode:      {"Control+V"},
+	actionCheatProfile:        {"Control+P"},
+	actionCheatSlowDown:       {"Control+Comma"},
+	actionCheatSpeedUp:        {"Control+Period"},
+	actionCheatSpawnVampires:  {"Control+Digit1"},
+	actionCheatSpawnBats:      {"Control+Digit2"},
+	actionCheatSpawnGhosts:    {"Control+Digit3"},
+	actionCheatIncreaseHealth: {"Control+Digit7"},
+	actionCheatSkipSouls:      {"Control+Minus"},
+	actionCheatNextLevel:      {"Control+Equal"},
+	actionCheatWin:            {"Control+Shift+Equal"},
+}
+
+// axisActions are actions with a value in the range -1 to 1 rather than a
+// pressed state.
+var axisActions = map[action]bool{
+	actionMoveX: true,
+	actionMoveY: true,
+	actionAimX:  true,
+	actionAimY:  true,
+}
+
+// keyboardMouse is the device of bindings to keys and mouse buttons. All other
+// devices are gamepads.
+const keyboardMouse ebiten.GamepadID = -1
+
+const (
+	bindingKey = iota
+	bindingMouse
+	bindingButton
+	bindingAxis
+)
+
+// axisPressThreshold is how far a gamepad axis must be moved to press an
+// action which is not an axis action.
+const axisPressThreshold = 0.5
+
+// binding is an input bound to an action.
+type binding struct {
+	bindingType int
+
+	key     ebiten.Key
+	control bool // Control must be held (keys only)
+	shift   bool // Shift must be held (keys only)
+
+	mouse  ebiten.MouseButton
+	button ebiten.StandardGamepadButton
+	axis   ebiten.StandardGamepadAxis
+
+	// sign is the direction of the binding: for axis actions, the value the
+	// binding is scaled by, and for gamepad axes bound to other actions, the
+	// direction the axis must be moved in.
+	sign float64
+}
+
+var mouseButtonNames = map[ebiten.MouseButton]string{
+	ebiten.MouseButtonLeft:   "Left",
+	ebiten.MouseButtonRight:  "Right",
+	ebiten.MouseButtonMiddle: "Middle",
+}
+
+var gamepadButtonNames = map[ebiten.StandardGamepadButton]string{
+	ebiten.StandardGamepadButtonRightBottom:      "RightBottom",
+	ebiten.StandardGamepadButtonRightRight:       "RightRight",
+	ebiten.StandardGamepadButtonRightLeft:        "RightLeft",
+	ebiten.StandardGamepadButtonRightTop:         "RightTop",
+	ebiten.StandardGamepadButtonFrontTopLeft:     "FrontTopLeft",
+	ebiten.StandardGamepadButtonFrontTopRight:    "FrontTopRight",
+	ebiten.StandardGamepadButtonFrontBottomLeft:  "FrontBottomLeft",
+	ebiten.StandardGamepadButtonFrontBottomRight: "FrontBottomRight",
+	ebiten.StandardGamepadButtonCenterLeft:       "CenterLeft",
+	ebiten.StandardGamepadButtonCenterRight:      "CenterRight",
+	ebiten.StandardGamepadButtonLeftStick:        "LeftStick",
+	ebiten.StandardGamepadButtonRightStick:       "RightStick",
+	ebiten.StandardGamepadButtonLeftTop:          "LeftTop",
+	ebiten.StandardGamepadButtonLeftBottom:       "LeftBottom",
+	ebiten.StandardGamepadButtonLeftLeft:         "LeftLeft",
+	ebiten.StandardGamepadButtonLeftRight:        "LeftRight",
+	ebiten.StandardGamepadButtonCenterCenter:     "CenterCenter",
+}
+
+// gamepadButtonLabels are the labels of gamepad buttons shown to the player.
+var gamepadButtonLabels = map[ebiten.StandardGamepadButton]string{
+	ebiten.StandardGamepadButtonRightBottom:      "A",
+	ebiten.StandardGamepadButtonRightRight:       "B",
+	ebiten.StandardGamepadButtonRightLeft:        "X",
+	ebiten.StandardGamepadButtonRightTop:         "Y",
+	ebiten.StandardGamepadButtonFrontTopLeft:     "LB",
+	ebiten.StandardGamepadButtonFrontTopRight:    "RB",
+	ebiten.StandardGamepadButtonFrontBottomLeft:  "LT",
+	ebiten.StandardGamepadButtonFrontBottomRight: "RT",
+	ebiten.StandardGamepadButtonCenterLeft:       "BACK",
+	ebiten.StandardGamepadButtonCenterRight:      "START",
+	ebiten.StandardGamepadButtonLeftStick:        "LS",
+	ebiten.StandardGamepadButtonRightStick:       "RS",
+	ebiten.StandardGamepadButtonLeftTop:          "DPAD UP",
+	ebiten.StandardGamepadButtonLeftBottom:       "DPAD DOWN",
+	ebiten.StandardGamepadButtonLeftLeft:         "DPAD LEFT",
+	ebiten.StandardGamepadButtonLeftRight:        "DPAD RIGHT",
+	ebiten.StandardGamepadButtonCenterCenter:     "GUIDE",
+}
+
+var gamepadAxisNames = map[ebiten.StandardGamepadAxis]string{
+	ebiten.StandardGamepadAxisLeftStickHorizontal:  "LeftStickHorizontal",
+	ebiten.StandardGamepadAxisLeftStickVertical:    "LeftStickVertical",
+	ebiten.StandardGamepadAxisRightStickHorizontal: "RightStickHorizontal",
+	ebiten.StandardGamepadAxisRightStickVertical:   "RightStickVertical",
+}
+
+// parseBinding parses a binding as saved in the controls file.
+func parseBinding(s string) (*binding, error) {
+	b := &binding{
+		sign: 1,
+	}
+
+	name := s
+	if strings.HasPrefix(name, "-") {
+		b.sign = -1
+		name = name[1:]
+	} else if strings.HasPrefix(name, "+") {
+		name = name[1:]
+	}
+
+	if strings.HasPrefix(name, "Mouse") {
+		b.bindingType = bindingMouse
+		for button, buttonName := range mouseButtonNames {
+			if name == "Mouse"+buttonName {
+				b.mouse = button
+				return b, nil
+			}
+		}
+	} else if strings.HasPrefix(name, "Gamepad") {
+		b.bindingType = bindingButton
+		for button, buttonName := range gamepadButtonNames {
+			if name == "Gamepad"+buttonName {
+				b.button = button
+				return b, nil
+			}
+		}
+		b.bindingType = bindingAxis
+		for axis, axisName := range gamepadAxisNames {
+			if name == "Gamepad"+axisName {
+				b.axis = axis
+				return b, nil
+			}
+		}
+	} else {
+		b.bindingType = bindingKey
+		for {
+			if strings.HasPrefix(name, "Control+") {
+				b.control = true
+				name = name[8:]
+			} else if strings.HasPrefix(name, "Shift+") {
+				b.shift = true
+				name = name[6:]
+			} else {
+				break
+			}
+		}
+		for k := ebiten.Key(0); k <= ebiten.KeyMax; k++ {
+			if k.String() == name {
+				b.key = k
+				return b, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("unknown input %s", s)
+}
+
+// String returns the binding as saved in the controls file.
+func (b *binding) String() string {
+	var s string
+	switch b.bindingType {
+	case bindingKey:
+		if b.control {
+			s += "Control+"
+		}
+		if b.shift {
+			s += "Shift+"
+		}
+		s += b.key.String()
+	case bindingMouse:
+		s = "Mouse" + mouseButtonNames[b.mouse]
+	case bindingButton:
+		s = "Gamepad" + gamepadButtonNames[b.button]
+	case bindingAxis:
+		s = "Gamepad" + gamepadAxisNames[b.axis]
+	}
+	if b.sign < 0 {
+		return "-" + s
+	} else if b.bindingType == bindingAxis {
+		return "+" + s
+	}
+	return s
+}
+
+// label returns the binding as shown to the player.
+func (b *binding) label() string {
+	switch b.bindingType {
+	case bindingKey:
+		var s string
+		if b.control {
+			s += "CTRL+"
+		}
+		if b.shift {
+			s += "SHIFT+"
+		}
+		return s + strings.ToUpper(strings.TrimPrefix(b.key.String(), "Digit"))
+	case bindingMouse:
+		return "MOUSE " + strings.ToUpper(mouseButtonNames[b.mouse])
+	case bindingButton:
+		return gamepadButtonLabels[b.button]
+	case bindingAxis:
+		stick := "LEFT STICK"
+		if b.axis == ebiten.StandardGamepadAxisRightStickHorizontal || b.axis == ebiten.StandardGamepadAxisRightStickVertical {
+			stick = "RIGHT STICK"
+		}
+		return stick
+	}
+	return ""
+}
+
+// gamepad returns whether the binding is a gamepad button or axis.
+func (b *binding) gamepad() bool {
+	return b.bindingType == bindingButton || b.bindingType == bindingAxis
+}
+
+// controls are the bindings of each action.
+type controls [numActions][]*binding
+
+func defaultControls() *controls {
+	c := &controls{}
+	for a, bindings := range defaultBindings {
+		for _, s := range bindings {
+			b, err := parseBinding(s)
+			if err != nil {
+				panic(err)
+			}
+			c[a] = append(c[a], b)
+		}
+	}
+	return c
+}
+
+// loadControls returns the saved controls. Actions which have not been saved
+// use the default bindings.
+func loadControls() *controls {
+	c := defaultControls()
+
+	data, err := readConfig(controlsFile)
+	if err != nil {
+		log.Printf("failed to read controls: %s", err)
+		return c
+	} else if data == nil {
+		return c
+	}
+
+	saved := make(map[string][]string)
+	err = json.Unmarshal(data, &saved)
+	if err != nil {
+		log.Printf("failed to read controls: %s", err)
+		return c
+	}
+
+	for a, name := range actionNames {
+		bindings, ok := saved[name]
+		if !ok {
+			continue
+		}
+
+		c[a] = nil
+		for _, s := range bindings {
+			b, err := parseBinding(s)
+			if err != nil {
+				log.Printf("failed to read controls: %s", err)
+				continue
+			}
+			c[a] = append(c[a], b)
+		}
+	}
+	return c
+}
+
+// saveControls saves the current controls.
+func (g *game) saveControls() {
+	saved := make(map[string][]string)
+	for a, name := range actionNames {
+		saved[name] = []string{}
+		for _, b := range g.controls[a] {
+			saved[name] = append(saved[name], b.String())
+		}
+	}
+
+	data, err := json.MarshalIndent(saved, "", "\t")
+	if err != nil {
+		log.Printf("failed to save controls: %s", err)
+		return
+	}
+
+	err = writeConfig(controlsFile, data)
+	if err != nil {
+		log.Printf("failed to save controls: %s", err)
+	}
+}
+
+// devices returns the keyboard and mouse followed by each connected gamepad.
+func (g *game) devices() []ebiten.GamepadID {
+	return append([]ebiten.GamepadID{keyboardMouse}, g.gamepadIDs...)
+}
+
+// bindingValue returns the value of a binding using the provided device in the
+// range -1 to 1. Gamepad axes within the dead zone have no value.
+func (g *game) bindingValue(b *binding, device ebiten.GamepadID) float64 {
+	if b.gamepad() != (device != keyboardMouse) {
+		return 0
+	}
+
+	switch b.bindingType {
+	case bindingKey:
+		if (b.control || b.shift) && (b.control != ebiten.IsKeyPressed(ebiten.KeyControl) || b.shift != ebiten.IsKeyPressed(ebiten.KeyShift)) {
+			return 0
+		}
+		if ebiten.IsKeyPressed(b.key) {
+			return b.sign
+		}
+	case bindingMouse:
+		if ebiten.IsMouseButtonPressed(b.mouse) {
+			return b.sign
+		}
+	case bindingButton:
+		if ebiten.IsStandardGamepadButtonPressed(device, b.button) {
+			return b.sign
+		}
+	case bindingAxis:
+		v := ebiten.StandardGamepadAxisValue(device, b.axis)
+		if math.Abs(v) > g.settings.GamepadDeadZone {
+			return v * b.sign
+		}
+	}
+	return 0
+}
+
+// updateActions updates the pressed state of all actions. It must be called
+// once at the start of each update.
+func (g *game) updateActions() {
+	g.actionsPressedLast = g.actionsPressed
+	g.actionsPressed = [numActions]bool{}
+	g.deviceActionsPressedLast = g.deviceActionsPressed
+	g.deviceActionsPressed = make(map[ebiten.GamepadID][numActions]bool)
+	for _, device := range g.devices() {
+		var pressed [numActions]bool
+		for a := action(0); a < numActions; a++ {
+			if axisActions[a] {
+				continue
+			}
+
+			for _, b := range g.controls[a] {
+				v := g.bindingValue(b, device)
+				if (b.bindingType == bindingAxis && v > axisPressThreshold) || (b.bindingType != bindingAxis && v != 0) {
+					pressed[a] = true
+					g.actionsPressed[a] = true
+					break
+				}
+			}
+		}
+		g.deviceActionsPressed[device] = pressed
+	}
+}
+
+// actionValue returns the value of an axis action using the provided device
+// in the range -1 to 1.
+func (g *game) actionValue(a action, device ebiten.GamepadID) float64 {
+	var v float64
+	for _, b := range g.controls[a] {
+		v += g.bindingValue(b, device)
+	}
+	return math.Max(-1, math.Min(1, v))
+}
+
+// actionPressed returns whether an action is pressed using any device.
+func (g *game) actionPressed(a action) bool {
+	return g.actionsPressed[a]
+}
+
+// actionJustPressed returns whether an action was pressed using any device
+// during the current update.
+func (g *game) actionJustPressed(a action) bool {
+	return g.actionsPressed[a] && !g.actionsPressedLast[a]
+}
+
+// deviceActionPressed returns whether an action is pressed using the provided
+// device.
+func (g *game) deviceActionPressed(a action, device ebiten.GamepadID) bool {
+	return g.deviceActionsPressed[device][a]
+}
+
+// deviceActionJustPressed returns whether an action was pressed using the
+// provided device during the current update.
+func (g *game) deviceActionJustPressed(a action, device ebiten.GamepadID) bool {
+	return g.deviceActionsPressed[device][a] && !g.deviceActionsPressedLast[device][a]
+}
+
+// actionLabel returns the bindings of an action in the provided direction as
+// shown to the player.
+func (g *game) actionLabel(a action, sign float64) string {
+	var labels []string
+	for _, b := range g.controls[a] {
+		// Gamepad axes are shown in both directions.
+		if b.bindingType != bindingAxis && b.sign != sign {
+			continue
+		}
+		labels = append(labels, b.label())
+	}
+	if len(labels) == 0 {
+		return "NONE"
+	}
+	return strings.Join(labels, ", ")
+}
+
+// pressedBinding returns an input pressed during the current update, if any.
+func (g *game) pressedBinding() *binding {
+	for k := ebiten.Key(0); k <= ebiten.KeyMax; k++ {
+		if !inpututil.IsKeyJustPressed(k) {
+			continue
+		}
+		switch k {
+		case ebiten.KeyShiftLeft, ebiten.KeyShiftRight:
+			k = ebiten.KeyShift
+		case ebiten.KeyControlLeft, ebiten.KeyControlRight:
+			k = ebiten.KeyControl
+		case ebiten.KeyAltLeft, ebiten.KeyAltRight:
+			k = ebiten.KeyAlt
+		}
+		return &binding{bindingType: bindingKey, key: k, sign: 1}
+	}
+	for button := range mouseButtonNames {
+		if inpututil.IsMouseButtonJustPressed(button) {
+			return &binding{bindingType: bindingMouse, mouse: button, sign: 1}
+		}
+	}
+	for _, id := range g.gamepadIDs {
+		for button := range gamepadButtonNames {
+			if inpututil.IsStandardGamepadButtonJustPressed(id, button) {
+				return &binding{bindingType: bindingButton, button: button, sign: 1}
+			}
+		}
+		for axis := range gamepadAxisNames {
+			v := ebiten.StandardGamepadAxisValue(id, axis)
+			if math.Abs(v) > axisPressThreshold {
+				// The sign of an axis binding is the direction it was moved in.
+				return &binding{bindingType: bindingAxis, axis: axis, sign: math.Copysign(1, v)}
+			}
+		}
+	}
+	return nil
+}
+
+// rebind binds an input to an action in the provided direction, replacing
+// any bindings of the same type of device in that direction.
+func (g *game) rebind(a action, sign float64, b *binding) {
+	if b.bindingType == bindingAxis && axisActions[a] {
+		// Moving an axis opposite to the direction being bound inverts it.
+		b.sign *= sign
+	} else if b.bindingType != bindingAxis {
+		b.sign = sign
+	}
+
+	replaces := func(existing *binding) bool {
+		if existing.gamepad() != b.gamepad() {
+			return false
+		}
+		if axisActions[a] && (existing.bindingType == bindingAxis || b.bindingType == bindingAxis) {
+			return existing.bindingType == b.bindingType
+		}
+		return existing.sign == b.sign
+	}
+
+	bindings := []*binding{b}
+	for _, existing := range g.controls[a] {
+		if !replaces(existing) {
+			bindings = append(bindings, existing)
+		}
+	}
+	g.controls[a] = bindings
+	g.saveControls()
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"testing"
+)
+
+// States of the game in which actions are read.
+const (
+	stateTitle = 1 << iota
+	statePlaying
+	stateGameOver
+	stateMenu
+)
+
+// actionStates are the states of the game in which each action is read.
+var actionStates = map[action]int{
+	actionMoveX:               statePlaying,
+	actionMoveY:               statePlaying,
+	actionAimX:                statePlaying,
+	actionAimY:                statePlaying,
+	actionFire:                statePlaying,
+	actionWalk:                statePlaying,
+	actionPause:               statePlaying | stateGameOver | stateMenu,
+	actionJoin:                statePlaying,
+	actionBack:                stateMenu,
+	actionSelect:              stateMenu,
+	actionMenuUp:              stateMenu,
+	actionMenuDown:            stateMenu,
+	actionMenuLeft:            stateMenu,
+	actionMenuRight:           stateMenu,
+	actionPlayAgain:           stateGameOver,
+	actionContinue:            stateTitle,
+	actionMute:                statePlaying,
+	actionQuickSave:           statePlaying,
+	actionQuickLoad:           statePlaying | stateGameOver,
+	actionZoomIn:              statePlaying,
+	actionZoomOut:             statePlaying,
+	actionCheatFullbright:     statePlaying,
+	actionCheatGodMode:        statePlaying,
+	actionCheatNoclipMode:     statePlaying,
+	actionCheatDebugMode:      statePlaying,
+	actionCheatProfile:        statePlaying,
+	actionCheatSlowDown:       statePlaying,
+	actionCheatSpeedUp:        statePlaying,
+	actionCheatSpawnVampires:  statePlaying,
+	actionCheatSpawnBats:      statePlaying,
+	actionCheatSpawnGhosts:    statePlaying,
+	actionCheatIncreaseHealth: statePlaying,
+	actionCheatSkipSouls:      statePlaying,
+	actionCheatNextLevel:      statePlaying,
+	actionCheatWin:            statePlaying,
+}
+
+// defaultInputs returns the inputs bound to an action by default. Keys and
+// buttons are pressed regardless of the direction they move an axis action in,
+// while a gamepad axis bound to an axis action is moved in both directions.
+func defaultInputs(t *testing.T, a action) []string {
+	var inputs []string
+	for _, s := range defaultBindings[a] {
+		b, err := parseBinding(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.bindingType != bindingAxis {
+			b.sign = 1
+		} else if axisActions[a] {
+			b.sign = -1
+			inputs = append(inputs, b.String())
+			b.sign = 1
+		}
+		inputs = append(inputs, b.String())
+	}
+	return inputs
+}
+
+func TestDefaultBindings(t *testing.T) {
+	for a := action(0); a < numActions; a++ {
+		if actionStates[a] == 0 {
+			t.Fatalf("action %s is not read in any state", actionNames[a])
+		}
+	}
+
+	for a := action(0); a < numActions; a++ {
+		for b := a + 1; b < numActions; b++ {
+			if actionStates[a]&actionStates[b] == 0 {
+				continue
+			}
+			for _, inputA := range defaultInputs(t, a) {
+				for _, inputB := range defaultInputs(t, b) {
+					if inputA == inputB {
+						t.Errorf("actions %s and %s share default binding %s", actionNames[a], actionNames[b], inputA)
+					}
+				}
+			}
+		}
+	}
+}
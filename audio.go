@@ -1,8 +1,11 @@
 package main
 
 import (
+	"fmt"
+	"io/fs"
 	"log"
 
+	"code.rocketnine.space/tslocum/carotidartillery/world"
 	"github.com/hajimehoshi/ebiten/v2/audio"
 	"github.com/hajimehoshi/ebiten/v2/audio/wav"
 )
@@ -16,6 +19,7 @@ const (
 	SoundPlayerDie
 	SoundPickup
 	SoundMunch
+	SoundGib
 )
 
 var soundMap = map[int]string{
@@ -27,6 +31,7 @@ var soundMap = map[int]string{
 	SoundPlayerDie:   "assets/audio/playerdie.wav",
 	SoundPickup:      "assets/audio/pickup.wav",
 	SoundMunch:       "assets/audio/munch.wav",
+	SoundGib:         "assets/audio/gib.wav",
 }
 var soundAtlas [][]*audio.Player
 
@@ -76,3 +81,128 @@ func loadSoundAtlas(ctx *audio.Context) [][]*audio.Player {
 	}
 	return atlas
 }
+
+// creepSoundSet holds the sounds of a type of creep.
+type creepSoundSet struct {
+	hit     []int
+	die     []int
+	ambient int // Sound played near players, or -1
+	fire    int // Sound played when firing a projectile, or -1
+}
+
+// creepSounds are the sounds of each type of creep.
+var creepSounds []*creepSoundSet
+
+// soundByName returns the sound with the provided name, which is the name of
+// its file without the extension.
+func soundByName(name string) (int, bool) {
+	for soundID, soundPath := range soundMap {
+		if soundPath == "assets/audio/"+name+".wav" {
+			return soundID, true
+		}
+	}
+	return 0, false
+}
+
+// loadCreepSounds returns the sounds of each type of creep, as named in the
+// creep definitions.
+func loadCreepSounds() ([]*creepSoundSet, error) {
+	sets := make([]*creepSoundSet, len(world.CreepDefinitions))
+	for creepType, def := range world.CreepDefinitions {
+		set := &creepSoundSet{
+			ambient: -1,
+			fire:    -1,
+		}
+		for _, name := range def.HitSounds {
+			soundID, ok := soundByName(name)
+			if !ok {
+				return nil, fmt.Errorf("unknown sound %s of creep %s", name, def.Name)
+			}
+			set.hit = append(set.hit, soundID)
+		}
+		for _, name := range def.DieSounds {
+			soundID, ok := soundByName(name)
+			if !ok {
+				return nil, fmt.Errorf("unknown sound %s of creep %s", name, def.Name)
+			}
+			set.die = append(set.die, soundID)
+		}
+		if def.AmbientSound != "" {
+			soundID, ok := soundByName(def.AmbientSound)
+			if !ok {
+				return nil, fmt.Errorf("unknown sound %s of creep %s", def.AmbientSound, def.Name)
+			}
+			set.ambient = soundID
+		}
+		if def.Ranged != nil && def.Ranged.Sound != "" {
+			soundID, ok := soundByName(def.Ranged.Sound)
+			if !ok {
+				return nil, fmt.Errorf("unknown sound %s of creep %s", def.Ranged.Sound, def.Name)
+			}
+			set.fire = soundID
+		}
+		sets[creepType] = set
+	}
+	return sets, nil
+}
+
+// musicPath returns the path of the named music.
+func musicPath(name string) string {
+	return "assets/audio/" + name + ".wav"
+}
+
+// checkLevelMusic checks that the music of each level exists.
+func checkLevelMusic() error {
+	for i, def := range world.LevelDefinitions {
+		if def.Music == "" {
+			continue
+		}
+		_, err := fs.Stat(assetsFS, musicPath(def.Music))
+		if err != nil {
+			return fmt.Errorf("unknown music %s of level %d", def.Music, i+1)
+		}
+	}
+	return nil
+}
+
+// playMusic plays the named music in a loop, replacing any music playing.
+// Music is stopped when no name is provided.
+func (g *game) playMusic(name string) error {
+	if name == g.musicName {
+		return nil
+	}
+	if g.music != nil {
+		g.music.Close()
+		g.music = nil
+	}
+	g.musicName = name
+	if name == "" {
+		return nil
+	}
+
+	f, err := assetsFS.Open(musicPath(name))
+	if err != nil {
+		return err
+	}
+	stream, err := wav.DecodeWithSampleRate(sampleRate, f)
+	if err != nil {
+		return err
+	}
+	g.music, err = g.audioContext.NewPlayer(audio.NewInfiniteLoop(stream, stream.Length()))
+	if err != nil {
+		return err
+	}
+	g.applyMusicVolume()
+	if !g.muteAudio {
+		g.music.Play()
+	}
+	return nil
+}
+
+// applyMusicVolume sets the volume of the music playing, if any, to the master
+// volume.
+func (g *game) applyMusicVolume() {
+	if g.music != nil {
+		g.music.SetVolume(g.settings.MasterVolume)
+	}
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"testing"
+
+	"code.rocketnine.space/tslocum/carotidartillery/world"
+)
+
+func TestLoadCreepSounds(t *testing.T) {
+	sets, err := loadCreepSounds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sets) != len(world.CreepDefinitions) {
+		t.Fatalf("expected sounds of %d creeps, got %d", len(world.CreepDefinitions), len(sets))
+	}
+	if sets[world.TypeBloodMage].fire == -1 {
+		t.Error("blood mage has no fire sound")
+	}
+}
@@ -0,0 +1,46 @@
+//go:build !js || !wasm
+// +build !js !wasm
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// configPath returns the path of the named file within the configuration
+// directory of the game.
+func configPath(name string) (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "carotidartillery", name), nil
+}
+
+// readConfig returns the contents of the named configuration file, or nil when
+// it does not exist.
+func readConfig(name string) ([]byte, error) {
+	p, err := configPath(name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(p)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// writeConfig writes the named configuration file.
+func writeConfig(name string, data []byte) error {
+	p, err := configPath(name)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(p), 0700)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(p, data, 0600)
+}
@@ -0,0 +1,26 @@
+//go:build js && wasm
+// +build js,wasm
+
+package main
+
+import (
+	"syscall/js"
+)
+
+const configPrefix = "carotidartillery/"
+
+// readConfig returns the contents of the named configuration entry in local
+// storage, or nil when it does not exist.
+func readConfig(name string) ([]byte, error) {
+	v := js.Global().Get("localStorage").Call("getItem", configPrefix+name)
+	if v.IsNull() || v.IsUndefined() {
+		return nil, nil
+	}
+	return []byte(v.String()), nil
+}
+
+// writeConfig writes the named configuration entry to local storage.
+func writeConfig(name string, data []byte) error {
+	js.Global().Get("localStorage").Call("setItem", configPrefix+name, string(data))
+	return nil
+}
@@ -5,14 +5,21 @@ package main
 
 import (
 	"flag"
+
+	"code.rocketnine.space/tslocum/carotidartillery/world"
 )
 
 func parseFlags(g *game) {
-	flag.BoolVar(&g.godMode, "god", false, "Enable God mode")
-	flag.BoolVar(&g.noclipMode, "noclip", false, "Enable noclip mode")
+	flag.BoolVar(&g.world.GodMode, "god", false, "Enable God mode")
+	flag.BoolVar(&g.world.NoclipMode, "noclip", false, "Enable noclip mode")
 	flag.BoolVar(&g.fullBrightMode, "fullbright", false, "Enable fullbright mode")
 	flag.BoolVar(&g.debugMode, "debug", false, "Enable debug mode")
-	flag.BoolVar(&g.muteAudio, "mute", false, "Mute audio")
-	flag.IntVar(&g.levelNum, "level", 0, "Warp to level")
+	flag.BoolVar(&g.muteAudio, "mute", g.muteAudio, "Mute audio")
+	flag.IntVar(&g.warpLevel, "level", 0, "Warp to level")
+	flag.IntVar(&g.world.Difficulty, "difficulty", world.DifficultyNormal, "Difficulty (0 = easy, 1 = normal, 2 = hard)")
+	flag.StringVar(&g.world.Generator, "generator", "", "Level generator (dungeon, cave or bsp)")
+	flag.Int64Var(&g.seed, "seed", 0, "Random seed (0 = random)")
+	flag.StringVar(&g.recordFile, "record", "", "Record replay to file")
+	flag.StringVar(&g.replayFile, "replay", "", "Play replay from file")
 	flag.Parse()
 }
@@ -4,5 +4,5 @@
 package main
 
 func parseFlags(g *game) {
-	g.disableEsc = true
+	g.disableQuit = true
 }
@@ -13,6 +13,7 @@ import (
 	"sync"
 	"time"
 
+	"code.rocketnine.space/tslocum/carotidartillery/world"
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/audio"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
@@ -28,23 +29,25 @@ var colorBlood = color.RGBA{102, 0, 0, 255}
 
 const (
 	gunshotVolume    = 0.2
-	vampireDieVolume = 0.15
-	batVolume        = 1.0
 	playerHurtVolume = 0.4
 	playerDieVolume  = 1.6
 	pickupVolume     = 0.8
 	munchVolume      = 0.6
 
-	spawnGarlic = 3
+	screenPadding = 33
 
-	garlicActiveTime = 7 * time.Second
+	bossScale = 2.0
+)
 
-	batSoundDelay = 250 * time.Millisecond
+// fedTint is the green and blue color scale applied to creeps for each soul
+// they have eaten.
+const fedTint = 0.8
 
-	screenPadding = 33
+// creepProjectileTint is the color scale of projectiles fired by creeps.
+var creepProjectileTint = [3]float64{1, 0.2, 0.2}
 
-	startingHealth = 3
-)
+// hitFlashTint is the color scale of creeps which were just hit.
+var hitFlashTint = [3]float64{4, 4, 4}
 
 var startButtons = []ebiten.StandardGamepadButton{
 	ebiten.StandardGamepadButtonRightBottom,
@@ -66,24 +69,15 @@ var startButtons = []ebiten.StandardGamepadButton{
 	ebiten.StandardGamepadButtonCenterCenter,
 }
 
-type projectile struct {
-	x, y       float64
-	angle      float64
-	speed      float64
-	color      color.Color
-	colorScale float64
-}
-
 var blackSquare = ebiten.NewImage(32, 32)
 
 // game is an isometric demo game.
 type game struct {
-	w, h  int
-	level *Level
+	w, h int
 
-	levelNum int
+	world *world.World
 
-	player *gamePlayer
+	warpLevel int
 
 	gameStartTime time.Time
 
@@ -95,27 +89,44 @@ type game struct {
 	winScreenSunY       float64
 	winScreenColorScale float64
 
+	camX, camY float64 // Position the camera is centered on
 	camScale   float64
 	camScaleTo float64
 
 	mousePanX, mousePanY int
 
-	projectiles []*projectile
-
 	overlayImg *ebiten.Image
 	op         *ebiten.DrawImageOptions
 
+	weaponSprite        *ebiten.Image
+	weaponSpriteFlipped *ebiten.Image
+
+	bloodSprites map[int64]*ebiten.Image
+	propSprites  map[*world.Creep]*ebiten.Image
+
 	audioContext *audio.Context
 
-	lastBatSound time.Time
+	themeLevel *world.Level  // Level whose theme and music were last applied
+	music      *audio.Player // Music playing, if any
+	musicName  string
 
 	gamepadIDs    []ebiten.GamepadID
 	gamepadIDsBuf []ebiten.GamepadID
-	activeGamepad ebiten.GamepadID
 
-	initialButtonReleased bool
+	players []*localPlayer // Local player controlling each player in the world
+
+	seed int64 // Seed provided via flag, or 0 to seed each game randomly
+
+	recordFile string
+	recording  *world.Replay
+
+	hasSave     bool // A quicksave exists
+	hasAutoSave bool // An autosave exists
 
-	tick int
+	replayFile   string
+	replay       *world.Replay
+	replayTick   int
+	pendingCheat int
 
 	flashMessageText  string
 	flashMessageUntil time.Time
@@ -123,10 +134,20 @@ type game struct {
 	minLevelColorScale  float64
 	minPlayerColorScale float64
 
-	disableEsc bool
+	menu *menu // Shown while the game is paused
+
+	settings *settings
+
+	controls           *controls
+	rebinding          *rebindTarget
+	actionsPressed     [numActions]bool
+	actionsPressedLast [numActions]bool
+
+	deviceActionsPressed     map[ebiten.GamepadID][numActions]bool
+	deviceActionsPressedLast map[ebiten.GamepadID][numActions]bool
+
+	disableQuit bool
 
-	godMode        bool
-	noclipMode     bool
 	muteAudio      bool
 	debugMode      bool
 	fullBrightMode bool
@@ -144,10 +165,14 @@ func NewGame() (*game, error) {
 		camScaleTo:          2,
 		mousePanX:           math.MinInt32,
 		mousePanY:           math.MinInt32,
-		activeGamepad:       -1,
 		minLevelColorScale:  -1,
 		minPlayerColorScale: -1,
 
+		bloodSprites: make(map[int64]*ebiten.Image),
+		propSprites:  make(map[*world.Creep]*ebiten.Image),
+
+		players: []*localPlayer{{device: keyboardMouse}},
+
 		op: &ebiten.DrawImageOptions{},
 	}
 
@@ -158,11 +183,18 @@ func NewGame() (*game, error) {
 		return nil, err
 	}
 
-	g.player, err = NewPlayer()
+	g.world, err = world.NewWorld()
 	if err != nil {
 		return nil, err
 	}
 
+	g.weaponSprite = imageAtlas[ImageUzi]
+
+	g.settings = loadSettings()
+	g.applySettings()
+
+	g.controls = loadControls()
+
 	blackSquare.Fill(color.Black)
 
 	return g, nil
@@ -172,7 +204,7 @@ func (g *game) flashMessage(message string) {
 	log.Println(message)
 
 	g.flashMessageText = message
-	g.flashMessageUntil = time.Now().Add(3 * time.Second)
+	g.flashMessageUntil = g.world.Clock.Now().Add(3 * time.Second)
 }
 
 func (g *game) loadAssets() error {
@@ -183,6 +215,11 @@ func (g *game) loadAssets() error {
 		return fmt.Errorf("failed to load embedded spritesheet: %s", err)
 	}
 
+	sandstoneSS, err = LoadEnvironmentSpriteSheet()
+	if err != nil {
+		return fmt.Errorf("failed to load embedded spritesheet: %s", err)
+	}
+
 	playerSS, err = LoadPlayerSpriteSheet()
 	if err != nil {
 		return fmt.Errorf("failed to load embedded spritesheet: %s", err)
@@ -193,108 +230,78 @@ func (g *game) loadAssets() error {
 		return fmt.Errorf("failed to load embedded spritesheet: %s", err)
 	}
 
+	levelThemes, err = loadLevelThemes()
+	if err != nil {
+		return fmt.Errorf("failed to load level themes: %s", err)
+	}
+	spriteAtlas = loadSpriteAtlas(sandstoneSS)
+	creepSprites, err = loadCreepSprites()
+	if err != nil {
+		return fmt.Errorf("failed to load creep sprites: %s", err)
+	}
+	batFormation = []*ebiten.Image{batSS.Formation1, batSS.Formation2, batSS.Formation3}
+	batSprites = loadBatSprites()
+
 	soundAtlas = loadSoundAtlas(g.audioContext)
+	creepSounds, err = loadCreepSounds()
+	if err != nil {
+		return fmt.Errorf("failed to load creep sounds: %s", err)
+	}
+	err = checkLevelMusic()
+	if err != nil {
+		return fmt.Errorf("failed to load level music: %s", err)
+	}
 
 	return nil
 }
 
-func (g *game) newItem(itemType int) *gameItem {
-	sprite := imageAtlas[ImageGarlic]
-	if itemType == itemTypeHolyWater {
-		sprite = imageAtlas[ImageHolyWater]
-	}
-	x, y := g.level.newSpawnLocation()
-	return &gameItem{
-		itemType: itemType,
-		x:        x,
-		y:        y,
-		sprite:   sprite,
-		level:    g.level,
-		player:   g.player,
-		health:   1,
+func (g *game) reset() error {
+	seed := g.seed
+	warpLevel := g.warpLevel
+	if g.replay != nil {
+		seed = g.replay.Seed
+		warpLevel = g.replay.Level
+		g.world.GodMode = g.replay.GodMode
+		g.world.NoclipMode = g.replay.NoclipMode
+		g.world.Difficulty = g.replay.Difficulty
+		g.world.Generator = g.replay.Generator
+		g.replayTick = 0
+	}
+	if seed == 0 {
+		seed = time.Now().UnixNano()
 	}
-}
 
-func (g *game) nextLevel() error {
-	g.player.soulsRescued = 0
+	// Only the first game is warped.
+	g.warpLevel = 0
 
-	g.levelNum++
-	if g.levelNum > 3 {
-		g.showWinScreen()
-		return nil
-	}
-	return g.generateLevel()
-}
+	g.pendingCheat = world.CheatNone
 
-func (g *game) generateLevel() error {
-	// Remove projectiles.
-	g.projectiles = nil
+	log.Printf("Starting a new game (seed %d)", seed)
 
-	// Remove creeps.
-	if g.level != nil {
-		g.level.creeps = nil
-	}
+	g.resetGameState()
 
-	var err error
-	g.level, err = NewLevel(g.levelNum, g.player)
+	err := g.world.Reset(seed)
 	if err != nil {
-		return fmt.Errorf("failed to create new level: %s", err)
-	}
-
-	// Position player.
-	if g.levelNum > 1 {
-		g.player.x, g.player.y = float64(g.level.enterX)+0.5, float64(g.level.enterY)-0.5
-	} else {
-		for {
-			g.player.x, g.player.y = float64(rand.Intn(g.level.w)), float64(rand.Intn(g.level.h))
-			if g.level.isFloor(g.player.x, g.player.y) {
-				break
-			}
-		}
+		return err
 	}
 
-	// Spawn items.
-	g.level.items = nil
-	for i := 0; i < spawnGarlic*g.levelNum; i++ {
-		itemType := itemTypeGarlic
-		c := g.newItem(itemType)
-		g.level.items = append(g.level.items, c)
-	}
-	// Spawn starting garlic.
-	item := g.newItem(itemTypeGarlic)
-	for {
-		garlicOffsetA := 8 - float64(rand.Intn(16))
-		garlicOffsetB := 8 - float64(rand.Intn(16))
-		startingGarlicX := g.player.x + 2 + garlicOffsetA
-		startingGarlicY := g.player.y + 2 + garlicOffsetB
-
-		if g.level.isFloor(startingGarlicX, startingGarlicY) {
-			item.x = startingGarlicX
-			item.y = startingGarlicY
-			break
+	if warpLevel > 1 {
+		err = g.world.Warp(warpLevel)
+		if err != nil {
+			return err
 		}
+		g.handleEvents()
 	}
-	g.level.items = append(g.level.items, item)
 
-	// Spawn starting creeps.
-	spawnAmount := 66
-	if g.levelNum == 2 {
-		spawnAmount = 133
-	} else if g.levelNum == 3 {
-		spawnAmount = 333
-	}
-	for i := 0; i < spawnAmount; i++ {
-		g.level.addCreep(TypeVampire)
+	if g.recordFile != "" {
+		g.recording = world.NewReplay(g.world)
 	}
 	return nil
 }
 
-func (g *game) reset() error {
-	log.Println("Starting a new game")
-
-	g.tick = 0
-
-	g.levelNum = 1
+// resetGameState resets the state of the game which is not part of the world.
+func (g *game) resetGameState() {
+	g.flashMessageUntil = time.Time{}
 
 	g.gameStartTime = time.Now()
 
@@ -306,24 +313,12 @@ func (g *game) reset() error {
 	g.minLevelColorScale = -1
 	g.minPlayerColorScale = -1
 
-	g.player.hasTorch = true
-	g.player.weapon = weaponUzi
-
-	err := g.generateLevel()
-	if err != nil {
-		return err
+	for seed := range g.bloodSprites {
+		delete(g.bloodSprites, seed)
+	}
+	for c := range g.propSprites {
+		delete(g.propSprites, c)
 	}
-
-	// Reset player score.
-	g.player.score = 0
-
-	// Reset souls rescued.
-	g.player.soulsRescued = 0
-
-	// Reset player health.
-	g.player.health = startingHealth
-
-	return nil
 }
 
 // Layout is called when the game's layout changes.
@@ -336,20 +331,20 @@ func (g *game) Layout(outsideWidth, outsideHeight int) (int, int) {
 		debugBox := image.NewRGBA(image.Rect(0, 0, g.w, 200))
 		g.overlayImg = ebiten.NewImageFromImage(debugBox)
 	}
-	if g.player.weapon != nil && g.player.weapon.spriteFlipped == nil {
+	if g.weaponSpriteFlipped == nil {
 		op := &ebiten.DrawImageOptions{}
 		op.GeoM.Scale(-1, 1)
 		op.GeoM.Translate(32, 0)
-		spriteFlipped := ebiten.NewImageFromImage(g.player.weapon.sprite)
+		spriteFlipped := ebiten.NewImageFromImage(g.weaponSprite)
 		spriteFlipped.Clear()
-		spriteFlipped.DrawImage(g.player.weapon.sprite, op)
-		g.player.weapon.spriteFlipped = spriteFlipped
+		spriteFlipped.DrawImage(g.weaponSprite, op)
+		g.weaponSpriteFlipped = spriteFlipped
 	}
 	return g.w, g.h
 }
 
 func (g *game) updateCursor() {
-	if g.activeGamepad != -1 || g.gameWon {
+	if !g.usingMouse() || g.gameWon {
 		ebiten.SetCursorMode(ebiten.CursorModeHidden)
 		return
 	}
@@ -357,12 +352,18 @@ func (g *game) updateCursor() {
 	ebiten.SetCursorShape(ebiten.CursorShapeCrosshair)
 }
 
-func (g *game) handlePlayerDeath() {
-	if g.player.health > 0 {
-		return
-	}
+func (g *game) handlePlayerDeath(p *world.Player) {
+	if g.world.Alive() {
+		for i, player := range g.world.Players {
+			if player == p {
+				g.flashMessage(fmt.Sprintf("PLAYER %d DIED", i+1))
+			}
+		}
+	} else {
+		g.gameOverTime = g.world.Clock.Now()
 
-	g.gameOverTime = time.Now()
+		g.saveReplay()
+	}
 
 	// Play die sound.
 	err := g.playSound(SoundPlayerDie, playerDieVolume)
@@ -374,63 +375,45 @@ func (g *game) handlePlayerDeath() {
 	g.updateCursor()
 }
 
-func (g *game) checkLevelComplete() {
-	if g.player.soulsRescued < g.level.requiredSouls || !g.level.exitOpenTime.IsZero() {
-		return
-	}
-	g.level.exitOpenTime = time.Now()
-
-	// TODO preserve existing floor sprite
-
-	t := g.level.tiles[g.level.exitY][g.level.exitX]
-	t.sprites = nil
-	t.AddSprite(sandstoneSS.FloorA)
-	t.AddSprite(sandstoneSS.TopDoorOpenTL)
-
-	t = g.level.tiles[g.level.exitY][g.level.exitX+1]
-	t.sprites = nil
-	t.AddSprite(sandstoneSS.FloorA)
-	t.AddSprite(sandstoneSS.TopDoorOpenTR)
-
-	t = g.level.tiles[g.level.exitY+1][g.level.exitX]
-	t.sprites = nil
-	t.AddSprite(sandstoneSS.FloorA)
-	t.AddSprite(sandstoneSS.TopDoorOpenBL)
-
-	t = g.level.tiles[g.level.exitY+1][g.level.exitX+1]
-	t.sprites = nil
-	t.AddSprite(sandstoneSS.FloorA)
-	t.AddSprite(sandstoneSS.TopDoorOpenBR)
-
-	for i := 1; i < 3; i++ {
-		t = g.level.tiles[g.level.exitY-i][g.level.exitX]
-		t.forceColorScale = 0
-
-		t = g.level.tiles[g.level.exitY-i][g.level.exitX+1]
-		t.forceColorScale = 0
-	}
-
-	// TODO add trigger entity or hardcode check
-}
-
 // Update reads current user input and updates the game state.
 func (g *game) Update() error {
 	g.Lock()
 	defer g.Unlock()
 
-	gamepadDeadZone := 0.1
-
-	if (!g.disableEsc && ebiten.IsKeyPressed(ebiten.KeyEscape)) || ebiten.IsWindowBeingClosed() {
+	if ebiten.IsWindowBeingClosed() {
 		g.exit()
 		return nil
 	}
 
+	g.updateActions()
+
+	if g.menu == nil && !ebiten.IsFocused() && !g.gameStartTime.IsZero() && g.gameOverTime.IsZero() {
+		g.pause()
+	}
+	if g.menu != nil {
+		return g.updateMenu()
+	}
+	if !g.gameStartTime.IsZero() && g.gameOverTime.IsZero() && g.updateJoin() {
+		return nil
+	}
+	if !g.gameStartTime.IsZero() && g.actionJustPressed(actionPause) && (g.gameOverTime.IsZero() || !g.actionJustPressed(actionPlayAgain)) {
+		g.pause()
+		return nil
+	}
+
 	if !g.gameOverTime.IsZero() {
+		g.world.Clock.Advance()
+
 		if g.gameWon {
 			return nil
 		}
 		// Game over.
-		if ebiten.IsKeyPressed(ebiten.KeyEnter) || (g.activeGamepad != -1 && ebiten.IsStandardGamepadButtonPressed(g.activeGamepad, ebiten.StandardGamepadButtonCenterRight)) {
+		if g.actionJustPressed(actionQuickLoad) {
+			return g.quickLoad()
+		}
+		if g.actionJustPressed(actionPlayAgain) {
+			g.replay = nil
+
 			err := g.reset()
 			if err != nil {
 				return err
@@ -448,97 +431,49 @@ func (g *game) Update() error {
 		if inpututil.IsGamepadJustDisconnected(id) {
 			log.Printf("gamepad disconnected: %d", id)
 			g.gamepadIDs = append(g.gamepadIDs[:i], g.gamepadIDs[i+1:]...)
+			g.disconnectGamepad(id)
 		}
+	}
 
-		if g.activeGamepad == -1 {
+	if g.gameStartTime.IsZero() {
+		// The first player uses the device which started the game.
+		device := keyboardMouse
+		var started bool
+		for _, id := range g.gamepadIDs {
 			for _, button := range startButtons {
 				if ebiten.IsStandardGamepadButtonPressed(id, button) {
 					log.Printf("gamepad activated: %d", id)
-					g.activeGamepad = id
-					g.updateCursor()
+					device, started = id, true
 					break
 				}
 			}
 		}
-	}
-
-	if g.gameStartTime.IsZero() {
-		var pressedKeys []ebiten.Key
-		pressedKeys = inpututil.AppendPressedKeys(pressedKeys)
-		if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) || g.activeGamepad != -1 || len(pressedKeys) > 0 {
-			g.gameStartTime = time.Now()
+		if !started {
+			var pressedKeys []ebiten.Key
+			pressedKeys = inpututil.AppendPressedKeys(pressedKeys)
+			started = ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) || len(pressedKeys) > 0
 		}
-		return nil
-	}
-
-	g.resetExpiredTimers()
-
-	liveCreeps := 0
-	for _, c := range g.level.creeps {
-		if c.health == 0 {
-			continue
-		}
-
-		c.Update()
-
-		if c.creepType == TypeTorch {
-			continue
-		}
-
-		biteThreshold := 0.75
-		if c.creepType == TypeSoul {
-			biteThreshold = 0.25
-		}
-
-		// TODO can this move into creep?
-		cx, cy := c.Position()
-		dx, dy := deltaXY(g.player.x, g.player.y, cx, cy)
-		if dx <= biteThreshold && dy <= biteThreshold {
-			if c.creepType == TypeSoul {
-				g.player.soulsRescued++
-				g.player.score += 13
-				err := g.hurtCreep(c, -1)
-				if err != nil {
-					// TODO
-					panic(err)
-				}
-				g.checkLevelComplete()
-			} else if !g.godMode && !c.repelled() {
-				err := g.hurtCreep(c, -1)
-				if err != nil {
-					// TODO
-					panic(err)
-				}
-
-				g.player.health--
-
-				if g.player.health == 2 {
-					g.playSound(SoundPlayerHurt, playerHurtVolume/2)
-				} else if g.player.health == 1 {
-					g.playSound(SoundPlayerHurt, playerHurtVolume)
-				}
-
-				g.addBloodSplatter(g.player.x, g.player.y)
-
-				g.handlePlayerDeath()
-			}
-		} else if c.creepType == TypeBat && (dx <= 12 && dy <= 7) && rand.Intn(166) == 6 && time.Since(g.lastBatSound) >= batSoundDelay {
-			g.playSound(SoundBat, batVolume)
-			g.lastBatSound = time.Now()
+		if !started {
+			return nil
 		}
+		g.players = []*localPlayer{{device: device}}
+		g.updateCursor()
 
-		if c.health > 0 {
-			liveCreeps++
+		if g.hasAutoSave && g.actionPressed(actionContinue) {
+			return g.continueGame()
 		}
+		g.gameStartTime = time.Now()
+		return nil
 	}
-	g.level.liveCreeps = liveCreeps
 
 	// Update target zoom level.
-	if g.debugMode {
+	if !g.debugMode {
+		g.camScaleTo = g.framingScale()
+	} else {
 		var scrollY float64
-		if ebiten.IsKeyPressed(ebiten.KeyC) || ebiten.IsKeyPressed(ebiten.KeyPageDown) {
+		if g.actionPressed(actionZoomOut) {
 			scrollY = -0.25
-		} else if ebiten.IsKeyPressed(ebiten.KeyE) || ebiten.IsKeyPressed(ebiten.KeyPageUp) {
+		} else if g.actionPressed(actionZoomIn) {
 			scrollY = .25
 		} else {
 			_, scrollY = ebiten.Wheel()
@@ -559,401 +494,279 @@ func (g *game) Update() error {
 		g.camScale -= (g.camScale - g.camScaleTo) / div
 	}
 
-	pan := 0.05
-
-	// Pan camera.
-	px, py := g.player.x, g.player.y
-	if g.activeGamepad != -1 {
-		h := ebiten.StandardGamepadAxisValue(g.activeGamepad, ebiten.StandardGamepadAxisLeftStickHorizontal)
-		v := ebiten.StandardGamepadAxisValue(g.activeGamepad, ebiten.StandardGamepadAxisLeftStickVertical)
-		if v < -gamepadDeadZone || v > gamepadDeadZone || h < -gamepadDeadZone || h > gamepadDeadZone {
-			px += h * pan
-			py += v * pan
-		}
-	} else {
-		if ebiten.IsKeyPressed(ebiten.KeyShift) {
-			pan /= 2
-		}
-
-		if ebiten.IsKeyPressed(ebiten.KeyLeft) || ebiten.IsKeyPressed(ebiten.KeyA) {
-			px -= pan
-		}
-		if ebiten.IsKeyPressed(ebiten.KeyRight) || ebiten.IsKeyPressed(ebiten.KeyD) {
-			px += pan
-		}
-		if ebiten.IsKeyPressed(ebiten.KeyDown) || ebiten.IsKeyPressed(ebiten.KeyS) {
-			py += pan
-		}
-		if ebiten.IsKeyPressed(ebiten.KeyUp) || ebiten.IsKeyPressed(ebiten.KeyW) {
-			py -= pan
-		}
-	}
-
-	if g.noclipMode || g.level.isFloor(px, py) {
-		g.player.x, g.player.y = px, py
-	} else if g.level.isFloor(px, g.player.y) {
-		g.player.x = px
-	} else if g.level.isFloor(g.player.x, py) {
-		g.player.y = py
-	}
-
-	for _, item := range g.level.items {
-		if item.health == 0 {
-			continue
-		}
-
-		dx, dy := deltaXY(g.player.x, g.player.y, item.x, item.y)
-		if dx <= 1 && dy <= 1 {
-			item.health = 0
-			g.player.score += item.useScore() * g.levelNum
-
-			if item.itemType == itemTypeGarlic {
-				g.playSound(SoundMunch, munchVolume)
-				g.player.garlicUntil = time.Now().Add(garlicActiveTime)
-			} else if item.itemType == itemTypeHolyWater {
-				g.playSound(SoundPickup, pickupVolume)
-				g.player.health++
-			}
-		}
-	}
-
-	fire := ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft)
-
-	// Update player angle.
-	if g.activeGamepad != -1 {
-		h := ebiten.StandardGamepadAxisValue(g.activeGamepad, ebiten.StandardGamepadAxisRightStickHorizontal)
-		v := ebiten.StandardGamepadAxisValue(g.activeGamepad, ebiten.StandardGamepadAxisRightStickVertical)
-		if v < -gamepadDeadZone || v > gamepadDeadZone || h < -gamepadDeadZone || h > gamepadDeadZone {
-			g.player.angle = angle(h, v, 0, 0)
-			fire = true
-		}
-	} else {
-		cx, cy := ebiten.CursorPosition()
-		g.player.angle = angle(float64(cx), float64(cy), float64(g.w/2), float64(g.h/2))
+	// Read user input.
+	if g.actionJustPressed(actionQuickSave) {
+		g.quickSave()
+	} else if g.actionJustPressed(actionQuickLoad) {
+		return g.quickLoad()
 	}
-
-	if !g.initialButtonReleased {
-		if fire {
-			fire = false
+	if g.actionJustPressed(actionMute) {
+		g.setMuteAudio(!g.muteAudio)
+		if g.muteAudio {
+			g.flashMessage("AUDIO MUTED")
 		} else {
-			g.initialButtonReleased = true
+			g.flashMessage("AUDIO UNMUTED")
 		}
 	}
-
-	// Update boolets.
-	bulletHitThreshold := 0.501
-	bulletSeekThreshold := 2.0
-	removed := 0
-UPDATEPROJECTILES:
-	for i, p := range g.projectiles {
-		if p.speed == 0 {
-			continue
-		}
-		speed := p.speed
-		for {
-			bx := p.x + math.Cos(p.angle)*speed
-			by := p.y + math.Sin(p.angle)*speed
-
-			if g.level.isFloor(bx, by) {
-				p.x, p.y = bx, by
-				break
-			}
-
-			speed *= .25
-			if speed < .001 {
-				// Remove projectile
-				p.speed = 0
-				p.colorScale = .01
-				continue UPDATEPROJECTILES
-			}
+	switch {
+	case g.actionJustPressed(actionCheatFullbright):
+		g.fullBrightMode = !g.fullBrightMode
+		if g.fullBrightMode {
+			g.flashMessage("FULLBRIGHT MODE ACTIVATED")
+		} else {
+			g.flashMessage("FULLBRIGHT MODE DEACTIVATED")
+		}
+	case g.actionJustPressed(actionCheatGodMode):
+		g.queueCheat(world.CheatGodMode)
+	case g.actionJustPressed(actionCheatNoclipMode):
+		g.queueCheat(world.CheatNoclipMode)
+	case g.actionJustPressed(actionCheatDebugMode):
+		g.debugMode = !g.debugMode
+		if g.debugMode {
+			g.flashMessage("DEBUG MODE ACTIVATED")
+		} else {
+			g.flashMessage("DEBUG MODE DEACTIVATED")
 		}
+	case g.actionJustPressed(actionCheatProfile):
+		if g.cpuProfile == nil {
+			g.flashMessage("CPU PROFILING STARTED")
 
-		for _, c := range g.level.creeps {
-			if c.health == 0 || c.creepType == TypeSoul {
-				continue
-			}
-
-			cx, cy := c.Position()
-			dx, dy := deltaXY(p.x, p.y, cx, cy)
-			if dx > bulletHitThreshold || dy > bulletHitThreshold {
-				if dx < bulletSeekThreshold && dy < bulletSeekThreshold {
-					c.seekPlayer()
-				}
-				continue
+			homeDir, err := os.UserHomeDir()
+			if err != nil {
+				return err
 			}
-
-			err := g.hurtCreep(c, 1)
+			g.cpuProfile, err = os.Create(path.Join(homeDir, "cartillery.prof"))
 			if err != nil {
 				return err
 			}
-
-			// Remove projectile
-			g.projectiles = append(g.projectiles[:i-removed], g.projectiles[i-removed+1:]...)
-			removed++
-
-			continue UPDATEPROJECTILES
-		}
-	}
-
-	// Fire boolets.
-	if fire && g.player.weapon != nil && time.Since(g.player.weapon.lastFire) >= g.player.weapon.cooldown {
-		p := &projectile{
-			x:          g.player.x,
-			y:          g.player.y,
-			angle:      g.player.angle,
-			speed:      0.35,
-			color:      colornames.Yellow,
-			colorScale: 1.0,
-		}
-		g.projectiles = append(g.projectiles, p)
-
-		g.player.weapon.lastFire = time.Now()
-
-		// Play gunshot sound.
-		err := g.playSound(SoundGunshot, gunshotVolume)
+			if err := pprof.StartCPUProfile(g.cpuProfile); err != nil {
+				return err
+			}
+		} else {
+			g.flashMessage("CPU PROFILING STOPPED")
+
+			pprof.StopCPUProfile()
+			g.cpuProfile.Close()
+			g.cpuProfile = nil
+		}
+	case g.actionJustPressed(actionCheatSlowDown):
+		speed := g.world.Clock.Speed() / 2
+		if speed < 0.125 {
+			speed = 0.125
+		}
+		g.world.Clock.SetSpeed(speed)
+		g.flashMessage(fmt.Sprintf("SIMULATION SPEED %gX", speed))
+	case g.actionJustPressed(actionCheatSpeedUp):
+		speed := g.world.Clock.Speed() * 2
+		if speed > 8 {
+			speed = 8
+		}
+		g.world.Clock.SetSpeed(speed)
+		g.flashMessage(fmt.Sprintf("SIMULATION SPEED %gX", speed))
+	case g.actionJustPressed(actionCheatSpawnVampires):
+		g.queueCheat(world.CheatSpawnVampires)
+	case g.actionJustPressed(actionCheatSpawnBats):
+		g.queueCheat(world.CheatSpawnBats)
+	case g.actionJustPressed(actionCheatSpawnGhosts):
+		g.queueCheat(world.CheatSpawnGhosts)
+	case g.actionJustPressed(actionCheatIncreaseHealth):
+		g.queueCheat(world.CheatIncreaseHealth)
+	case g.actionJustPressed(actionCheatWin):
+		g.queueCheat(world.CheatWin)
+	case g.actionJustPressed(actionCheatSkipSouls):
+		g.queueCheat(world.CheatSkipSouls)
+	case g.actionJustPressed(actionCheatNextLevel):
+		g.queueCheat(world.CheatNextLevel)
+	}
+
+	for steps := g.world.Clock.Steps(); steps > 0 && g.gameOverTime.IsZero(); steps-- {
+		err := g.world.Step(g.nextInput())
 		if err != nil {
 			return err
 		}
-	}
 
-	// Remove dead creeps.
-	if g.tick%200 == 0 {
-		removed = 0
-		for i, creep := range g.level.creeps {
-			if creep.health != 0 || creep.creepType == TypeTorch || creep.creepType == TypeSoul {
-				continue
-			}
+		g.handleEvents()
+	}
+	return g.applyLevelTheme()
+}
 
-			// Remove creep.
-			g.level.creeps = append(g.level.creeps[:i-removed], g.level.creeps[i-removed+1:]...)
-			removed++
-		}
+// applyLevelTheme applies the tile set and music of the current level once it
+// has been generated or loaded.
+func (g *game) applyLevelTheme() error {
+	if g.world.Level == g.themeLevel {
+		return nil
 	}
+	g.themeLevel = g.world.Level
 
-	// Spawn garlic.
-	if (g.tick > 0 && g.tick%(144*45) == 0) || rand.Intn(6666) == 0 {
-		item := g.newItem(itemTypeGarlic)
-		g.level.items = append(g.level.items, item)
+	def := g.world.LevelDefinition()
+	if def == nil {
+		return nil
+	}
+	spriteAtlas = loadSpriteAtlas(levelThemes[def.Theme])
+	return g.playMusic(def.Music)
+}
 
-	SPAWNGARLIC:
-		for i := 0; i < 5; i++ {
-			for _, levelItem := range g.level.items {
-				if levelItem != item && item.itemType == itemTypeGarlic {
-					dx, dy := deltaXY(item.x, item.y, levelItem.x, levelItem.y)
-					if dx < 21 || dy < 21 {
-						item.x, item.y = g.level.newSpawnLocation()
-						continue SPAWNGARLIC
-					}
-				}
-			}
-			break
-		}
+// queueCheat applies a cheat during the next tick. Cheats are passed to the
+// world as input so that they are recorded in replays.
+func (g *game) queueCheat(cheat int) {
+	if g.replay != nil {
+		return
+	}
+	g.pendingCheat = cheat
+}
 
-		if g.debugMode {
-			g.flashMessage("SPAWN GARLIC")
+// nextInput returns the input of each player during the next tick, which is
+// read from the replay being played back or from the local players.
+func (g *game) nextInput() []world.Input {
+	var inputs []world.Input
+	if g.replay != nil {
+		if g.replayTick < len(g.replay.Inputs) {
+			inputs = g.replay.Inputs[g.replayTick]
+			g.replayTick++
+		} else {
+			g.replay = nil
+			g.flashMessage("REPLAY FINISHED")
 		}
 	}
-
-	// Spawn holy water.
-	if g.tick%(144*30) == 0 || rand.Intn(6666) == 0 {
-		item := g.newItem(itemTypeHolyWater)
-		g.level.items = append(g.level.items, item)
-
-	SPAWNHOLYWATER:
-		for i := 0; i < 5; i++ {
-			for _, levelItem := range g.level.items {
-				if levelItem != item && item.itemType == itemTypeHolyWater {
-					dx, dy := deltaXY(item.x, item.y, levelItem.x, levelItem.y)
-					if dx < 21 || dy < 21 {
-						item.x, item.y = g.level.newSpawnLocation()
-						continue SPAWNHOLYWATER
-					}
-				}
-			}
-			break
+	if g.replay == nil {
+		inputs = make([]world.Input, len(g.players))
+		for i, lp := range g.players {
+			inputs[i] = g.readInput(lp, i).Quantize()
 		}
+		inputs[0].Cheat = g.pendingCheat
+		g.pendingCheat = world.CheatNone
+	}
 
-		if g.debugMode {
-			g.flashMessage("SPAWN HOLY WATER")
-		}
+	if g.recording != nil {
+		g.recording.Record(inputs)
 	}
+	return inputs
+}
 
-	maxCreeps := 333
-	if g.levelNum == 2 {
-		maxCreeps = 666
-	} else if g.levelNum == 3 {
-		maxCreeps = 999
+// readInput returns the current input of a local player. Players which are
+// not controlled by any device stand still.
+func (g *game) readInput(lp *localPlayer, i int) world.Input {
+	var p *world.Player
+	var in world.Input
+	if i < len(g.world.Players) {
+		p = g.world.Players[i]
+		in.Angle = p.Angle
 	}
-	if len(g.level.creeps) < maxCreeps {
-		// Spawn vampires.
-		if g.tick%144 == 0 {
-			spawnAmount := rand.Intn(1 + (g.tick / (144 * 9)))
-			minCreeps := g.level.requiredSouls * 2
-			if len(g.level.creeps) < minCreeps {
-				spawnAmount *= 4
-			}
-			if g.debugMode && spawnAmount > 0 {
-				g.flashMessage(fmt.Sprintf("SPAWN %d VAMPIRES", spawnAmount))
-			}
-			for i := 0; i < spawnAmount; i++ {
-				g.level.addCreep(TypeVampire)
-			}
-		}
+	if lp == nil {
+		return in
+	}
+	device := lp.device
 
-		// Spawn bats.
-		if g.tick%(144*(4-g.levelNum)) == 0 {
-			spawnAmount := g.tick / 288
-			if spawnAmount < 1 {
-				spawnAmount = 1
-			} else if spawnAmount > 12 {
-				spawnAmount = 12
-			}
-			spawnAmount = rand.Intn(spawnAmount)
-			if g.debugMode && spawnAmount > 0 {
-				g.flashMessage(fmt.Sprintf("SPAWN %d BATS", spawnAmount))
-			}
-			for i := 0; i < spawnAmount; i++ {
-				g.level.addCreep(TypeBat)
-			}
-		}
+	in.MoveX = g.actionValue(actionMoveX, device)
+	in.MoveY = g.actionValue(actionMoveY, device)
+	in.Fire = g.deviceActionPressed(actionFire, device)
 
-		// Spawn ghosts.
-		if false && g.tick%1872 == 0 { // Auto-spawn disabled.
-			spawnAmount := g.tick / 1872
-			if spawnAmount < 1 {
-				spawnAmount = 1
-			} else if spawnAmount > 6 {
-				spawnAmount = 6
-			}
-			spawnAmount = rand.Intn(spawnAmount)
-			if g.debugMode && spawnAmount > 0 {
-				g.flashMessage(fmt.Sprintf("SPAWN %d GHOSTS", spawnAmount))
-			}
-			for i := 0; i < spawnAmount; i++ {
-				g.level.addCreep(TypeGhost)
-			}
-		}
+	// Read movement.
+	if g.deviceActionPressed(actionWalk, device) {
+		in.MoveX /= 2
+		in.MoveY /= 2
 	}
 
-	// Read user input.
-	if inpututil.IsKeyJustPressed(ebiten.KeyM) {
-		g.muteAudio = !g.muteAudio
-		if g.muteAudio {
-			g.flashMessage("AUDIO MUTED")
+	// Read player angle.
+	aimX, aimY := g.actionValue(actionAimX, device), g.actionValue(actionAimY, device)
+	if aimX != 0 || aimY != 0 {
+		in.Angle = world.Angle(aimX, aimY, 0, 0)
+		in.Fire = true
+	} else if device == keyboardMouse && p != nil {
+		cx, cy := ebiten.CursorPosition()
+		px, py := g.levelCoordinatesToScreen(g.tilePosition(p.X, p.Y))
+		in.Angle = world.Angle(float64(cx), float64(cy), px, py)
+	}
+
+	if !lp.initialButtonReleased {
+		if in.Fire {
+			in.Fire = false
 		} else {
-			g.flashMessage("AUDIO UNMUTED")
+			lp.initialButtonReleased = true
 		}
 	}
-	if ebiten.IsKeyPressed(ebiten.KeyControl) {
-		spawnAmount := 13
-		switch {
-		case inpututil.IsKeyJustPressed(ebiten.KeyF):
-			g.fullBrightMode = !g.fullBrightMode
-			if g.fullBrightMode {
-				g.flashMessage("FULLBRIGHT MODE ACTIVATED")
-			} else {
-				g.flashMessage("FULLBRIGHT MODE DEACTIVATED")
+
+	return in
+}
+
+// handleEvents plays sounds and updates the game state in response to the
+// events of the world, then clears them.
+func (g *game) handleEvents() {
+	for _, e := range g.world.Events {
+		switch e.EventType {
+		case world.EventFire:
+			g.playSound(SoundGunshot, gunshotVolume)
+		case world.EventCreepFire:
+			if sound := creepSounds[e.Creep.CreepType].fire; sound != -1 {
+				g.playSound(sound, e.Creep.Definition().Ranged.Volume)
 			}
-		case inpututil.IsKeyJustPressed(ebiten.KeyG):
-			g.godMode = !g.godMode
-			if g.godMode {
-				g.flashMessage("GOD MODE ACTIVATED")
-			} else {
-				g.flashMessage("GOD MODE DEACTIVATED")
+		case world.EventCreepHit:
+			sounds := creepSounds[e.Creep.CreepType]
+			if len(sounds.hit) > 0 {
+				g.playSound(sounds.hit[rand.Intn(len(sounds.hit))], e.Creep.Definition().HitVolume)
 			}
-		case inpututil.IsKeyJustPressed(ebiten.KeyN):
-			g.noclipMode = !g.noclipMode
-			if g.noclipMode {
-				g.flashMessage("NOCLIP MODE ACTIVATED")
-			} else {
-				g.flashMessage("NOCLIP MODE DEACTIVATED")
+		case world.EventCreepKilled:
+			if e.Creep.Definition().Boss {
+				g.flashMessage("VAMPIRE LORD DEFEATED")
 			}
-		case inpututil.IsKeyJustPressed(ebiten.KeyV):
-			g.debugMode = !g.debugMode
-			if g.debugMode {
-				g.flashMessage("DEBUG MODE ACTIVATED")
-			} else {
-				g.flashMessage("DEBUG MODE DEACTIVATED")
+
+			sounds := creepSounds[e.Creep.CreepType]
+			if len(sounds.die) == 0 {
+				continue
 			}
-		case inpututil.IsKeyJustPressed(ebiten.KeyP):
-			if g.cpuProfile == nil {
-				g.flashMessage("CPU PROFILING STARTED")
 
-				homeDir, err := os.UserHomeDir()
-				if err != nil {
-					return err
-				}
-				g.cpuProfile, err = os.Create(path.Join(homeDir, "cartillery.prof"))
-				if err != nil {
-					return err
-				}
-				if err := pprof.StartCPUProfile(g.cpuProfile); err != nil {
-					return err
-				}
-			} else {
-				g.flashMessage("CPU PROFILING STOPPED")
+			// Play die sound.
+			dieSound := sounds.die[rand.Intn(len(sounds.die))]
+			volume := e.Creep.Definition().DieVolume
 
-				pprof.StopCPUProfile()
-				g.cpuProfile.Close()
-				g.cpuProfile = nil
+			dx, dy := world.DeltaXY(g.camX, g.camY, e.X, e.Y)
+			distance := dx
+			if dy > dx {
+				distance = dy
 			}
-		case inpututil.IsKeyJustPressed(ebiten.Key1):
-			for i := 0; i < spawnAmount; i++ {
-				g.level.addCreep(TypeVampire)
+			if distance > 9 {
+				volume *= 0.7
+			} else if distance > 6 {
+				volume *= 0.85
 			}
-			g.flashMessage(fmt.Sprintf("SPAWNED %d VAMPIRES", spawnAmount))
-		case inpututil.IsKeyJustPressed(ebiten.Key2):
-			for i := 0; i < spawnAmount; i++ {
-				g.level.addCreep(TypeBat)
+
+			g.playSound(dieSound, volume)
+		case world.EventSoulEaten:
+			dx, dy := world.DeltaXY(g.camX, g.camY, e.X, e.Y)
+			if dx <= 12 && dy <= 12 {
+				g.playSound(SoundMunch, munchVolume/4)
 			}
-			g.flashMessage(fmt.Sprintf("SPAWNED %d BATS", spawnAmount))
-		case inpututil.IsKeyJustPressed(ebiten.Key3):
-			for i := 0; i < spawnAmount; i++ {
-				g.level.addCreep(TypeGhost)
+		case world.EventLevelComplete:
+			g.flashMessage(e.Message)
+		case world.EventPlayerHurt:
+			if e.Player.Health == 2 {
+				g.playSound(SoundPlayerHurt, playerHurtVolume/2)
+			} else if e.Player.Health == 1 {
+				g.playSound(SoundPlayerHurt, playerHurtVolume)
 			}
-			g.flashMessage(fmt.Sprintf("SPAWNED %d GHOSTS", spawnAmount))
-		case inpututil.IsKeyJustPressed(ebiten.Key7):
-			g.player.health++
-			g.flashMessage("INCREASED HEALTH")
-		case inpututil.IsKeyJustPressed(ebiten.Key8):
-			// TODO Add garlic to inventory
-			//g.flashMessage("+ GARLIC")
-		case ebiten.IsKeyPressed(ebiten.KeyShift) && inpututil.IsKeyJustPressed(ebiten.KeyEqual):
-			g.showWinScreen()
-			g.flashMessage("WARPED TO WIN SCREEN")
-		case inpututil.IsKeyJustPressed(ebiten.KeyMinus):
-			if g.player.soulsRescued < g.level.requiredSouls {
-				g.player.soulsRescued = g.level.requiredSouls
-				g.checkLevelComplete()
-				g.flashMessage("SKIPPED SOUL COLLECTION")
-			} else {
-				g.player.x, g.player.y = float64(g.level.exitX)+0.5, float64(g.level.exitY+2)
-				g.flashMessage("WARPED TO EXIT")
+		case world.EventPlayerDied:
+			g.handlePlayerDeath(e.Player)
+		case world.EventPickup:
+			if e.Item.ItemType == world.ItemTypeGarlic {
+				g.playSound(SoundMunch, munchVolume)
+			} else if e.Item.ItemType == world.ItemTypeHolyWater {
+				g.playSound(SoundPickup, pickupVolume)
 			}
-		case inpututil.IsKeyJustPressed(ebiten.KeyEqual):
-			err := g.nextLevel()
-			if err != nil {
-				return err
+		case world.EventCreepSound:
+			if sound := creepSounds[e.Creep.CreepType].ambient; sound != -1 {
+				g.playSound(sound, e.Creep.Definition().AmbientVolume)
 			}
-			g.flashMessage(fmt.Sprintf("WARPED TO LEVEL %d", g.levelNum))
-		}
-	}
-
-	// Check if player is exiting level.
-	if !g.level.exitOpenTime.IsZero() {
-		exitThreshold := 1.1
-		dx1, dy1 := deltaXY(g.player.x, g.player.y, float64(g.level.exitX), float64(g.level.exitY))
-		dx2, dy2 := deltaXY(g.player.x, g.player.y, float64(g.level.exitX+1), float64(g.level.exitY))
-		if (dx1 <= exitThreshold && dy1 <= exitThreshold) || (dx2 <= exitThreshold && dy2 <= exitThreshold) {
-			err := g.nextLevel()
-			if err != nil {
-				return err
+		case world.EventWin:
+			g.showWinScreen()
+		case world.EventMessage:
+			if g.debugMode {
+				g.flashMessage(e.Message)
 			}
+		case world.EventCheat:
+			g.flashMessage(e.Message)
 		}
 	}
-
-	g.tick++
-	return nil
+	g.world.Events = g.world.Events[:0]
 }
 
 func (g *game) drawText(target *ebiten.Image, x float64, y float64, scale float64, alpha float64, text string) {
@@ -989,16 +802,27 @@ func (g *game) Draw(screen *ebiten.Image) {
 		g.drawCenteredText(screen, 0, float64(g.h/2)-350, 16, 1.0, "CAROTID")
 		g.drawCenteredText(screen, 0, float64(g.h/2)-100, 16, 1.0, "ARTILLERY")
 
-		g.drawCenteredText(screen, 0, float64(g.h-210), 4, 1.0, "WASD + MOUSE = OK")
-		g.drawCenteredText(screen, 0, float64(g.h-145), 4, 1.0, "FULLSCREEN + GAMEPAD = BEST")
+		// Make room for the continue prompt.
+		var offset int
+		if g.hasAutoSave {
+			offset = 65
+		}
+
+		g.drawCenteredText(screen, 0, float64(g.h-210-offset), 4, 1.0, "WASD + MOUSE = OK")
+		g.drawCenteredText(screen, 0, float64(g.h-145-offset), 4, 1.0, "FULLSCREEN + GAMEPAD = BEST")
 
 		if time.Now().UnixMilli()%2000 < 1500 {
-			g.drawCenteredText(screen, 0, float64(g.h-80), 4, 1.0, "PRESS ANY KEY OR BUTTON TO START")
+			g.drawCenteredText(screen, 0, float64(g.h-80-offset), 4, 1.0, "PRESS ANY KEY OR BUTTON TO START")
+			if g.hasAutoSave {
+				g.drawCenteredText(screen, 0, float64(g.h-80), 4, 1.0, "PRESS C OR Y TO CONTINUE")
+			}
 		}
 
 		return
 	}
 
+	g.updateCamera()
+
 	var drawn int
 	if g.gameOverTime.IsZero() || g.gameWon {
 		if g.gameWon {
@@ -1020,7 +844,7 @@ func (g *game) Draw(screen *ebiten.Image) {
 	} else {
 		drawn += g.drawProjectiles(screen)
 
-		drawn += g.drawPlayer(screen)
+		drawn += g.drawPlayers(screen)
 
 		// Draw game over screen.
 		img := ebiten.NewImage(g.w, g.h)
@@ -1039,28 +863,35 @@ func (g *game) Draw(screen *ebiten.Image) {
 
 		g.drawCenteredText(screen, 0, float64(g.h/2)-150, 16, a, "GAME OVER")
 
-		if time.Since(g.gameOverTime).Milliseconds()%2000 < 1500 {
-			g.drawCenteredText(screen, 0, 8, 4, a, "PRESS ENTER OR START TO PLAY AGAIN")
+		if g.world.Clock.Since(g.gameOverTime).Milliseconds()%2000 < 1500 {
+			g.drawCenteredText(screen, 0, 8, 4, a, "PRESS ENTER OR A TO PLAY AGAIN")
 		}
 	}
 
 	if g.gameOverTime.IsZero() {
-		// Draw health.
+		// Draw health of each player, with the first player at the bottom.
 		healthScale := 1.5
 		heartSpace := int(32 * healthScale)
-		heartY := float64(g.h - screenPadding - heartSpace)
-		for i := 0; i < g.player.health; i++ {
-			g.op.GeoM.Reset()
-			g.op.GeoM.Scale(healthScale, healthScale)
-			g.op.GeoM.Translate(screenPadding+(float64((i)*heartSpace)), heartY)
-			screen.DrawImage(imageAtlas[ImageHeart], g.op)
+		for i, p := range g.world.Players {
+			heartY := float64(g.h - screenPadding - heartSpace*(i+1))
+			c := playerColors[i%maxPlayers]
+			for j := 0; j < p.Health; j++ {
+				g.op.GeoM.Reset()
+				g.op.GeoM.Scale(healthScale, healthScale)
+				g.op.GeoM.Translate(screenPadding+(float64((j)*heartSpace)), heartY)
+				g.op.ColorM.Scale(c[0], c[1], c[2], 1)
+				screen.DrawImage(imageAtlas[ImageHeart], g.op)
+				g.op.ColorM.Reset()
+			}
 		}
 
+		g.drawBossHealth(screen)
+
 		scale := 5.0
 		soulsY := float64(g.h-int(scale*14)) - screenPadding
-		if g.level.exitOpenTime.IsZero() {
+		if g.world.Level.ExitOpenTime.IsZero() {
 			// Draw souls.
-			soulsLabel := fmt.Sprintf("%d", g.level.requiredSouls-g.player.soulsRescued)
+			soulsLabel := fmt.Sprintf("%d", g.world.Level.RequiredSouls-g.world.SoulsRescued)
 
 			soulImgSize := 46.0
 
@@ -1075,13 +906,13 @@ func (g *game) Draw(screen *ebiten.Image) {
 			g.drawText(screen, soulsX, soulsY, scale, 1.0, soulsLabel)
 		} else {
 			// Draw exit message.
-			if time.Since(g.level.exitOpenTime).Milliseconds()%2000 < 1500 {
+			if g.world.Clock.Since(g.world.Level.ExitOpenTime).Milliseconds()%2000 < 1500 {
 				g.drawText(screen, float64(g.w-screenPadding)-(float64(9)*scale*6), soulsY, scale, 1.0, "EXIT OPEN")
 			}
 		}
 	}
 
-	flashTime := g.flashMessageUntil.Sub(time.Now())
+	flashTime := g.world.Clock.Until(g.flashMessageUntil)
 	if flashTime > 0 {
 		alpha := flashTime.Seconds() * 4
 		if alpha > 1 {
@@ -1096,31 +927,142 @@ func (g *game) Draw(screen *ebiten.Image) {
 			a = 1
 		}
 		scale := 5
-		scoreLabel := numberPrinter.Sprintf("%d", g.player.score)
-		g.drawCenteredText(screen, 0, float64(g.h-(scale*14))-screenPadding, float64(scale), a, scoreLabel)
+		if len(g.world.Players) == 1 {
+			scoreLabel := numberPrinter.Sprintf("%d", g.world.Players[0].Score)
+			g.drawCenteredText(screen, 0, float64(g.h-(scale*14))-screenPadding, float64(scale), a, scoreLabel)
+		} else {
+			// List the score of each player, with the first player at the top.
+			scale = 4
+			for i, p := range g.world.Players {
+				scoreLabel := numberPrinter.Sprintf("P%d %d", i+1, p.Score)
+				y := g.h - (scale * 14 * (len(g.world.Players) - i)) - screenPadding
+				g.drawCenteredText(screen, 0, float64(y), float64(scale), a, scoreLabel)
+			}
+		}
 	}
 
-	if !g.debugMode {
+	if g.menu != nil {
+		g.drawMenu(screen)
+	}
+
+	if !g.debugMode && !g.settings.DebugOverlay {
 		return
 	}
 
 	// Print game info.
 	g.overlayImg.Clear()
-	ebitenutil.DebugPrint(g.overlayImg, fmt.Sprintf("CRP  %d\nSPR  %d\nTPS  %0.0f\nFPS  %0.0f", g.level.liveCreeps, drawn, ebiten.CurrentTPS(), ebiten.CurrentFPS()))
+	ebitenutil.DebugPrint(g.overlayImg, fmt.Sprintf("CRP  %d\nFAR  %d\nSLP  %d\nPRS  %0.2f\nSPR  %d\nTPS  %0.0f\nFPS  %0.0f\nSEED %d", g.world.Level.LiveCreeps, g.world.Level.FarCreeps, g.world.Level.SleepingCreeps, g.world.Pressure(), drawn, ebiten.CurrentTPS(), ebiten.CurrentFPS(), g.world.Seed))
 	g.op.GeoM.Reset()
 	g.op.GeoM.Translate(3, 0)
 	g.op.GeoM.Scale(2, 2)
 	screen.DrawImage(g.overlayImg, g.op)
 }
 
+// drawBossHealth draws the health bar of the boss of the current level while
+// it is alive.
+func (g *game) drawBossHealth(screen *ebiten.Image) {
+	boss := g.world.Level.Boss
+	if boss == nil || boss.Health <= 0 {
+		return
+	}
+
+	const barHeight = 16.0
+	barWidth := float64(g.w) / 2
+	barX, barY := float64(g.w)/4, float64(screenPadding+32)
+	health := float64(boss.Health) / float64(boss.Definition().Health)
+
+	g.drawCenteredText(screen, 0, screenPadding, 2, 1.0, "VAMPIRE LORD")
+
+	// Draw background.
+	g.op.GeoM.Reset()
+	g.op.GeoM.Scale(barWidth/32, barHeight/32)
+	g.op.GeoM.Translate(barX, barY)
+	screen.DrawImage(blackSquare, g.op)
+
+	// Draw remaining health.
+	g.op.GeoM.Reset()
+	g.op.GeoM.Scale(barWidth*health/32, barHeight/32)
+	g.op.GeoM.Translate(barX, barY)
+	g.op.ColorM.Translate(float64(colorBlood.R)/0xff*2, 0, 0, 0)
+	screen.DrawImage(blackSquare, g.op)
+	g.op.ColorM.Reset()
+}
+
+// visiblePlayers returns the players which are shown. Only the first player
+// is shown on the win screen.
+func (g *game) visiblePlayers() []*world.Player {
+	if g.gameWon {
+		return g.world.Players[:1]
+	}
+	return g.world.Players
+}
+
+// cameraPlayers returns the players the camera frames, which are the living
+// players, or all visible players when none are alive.
+func (g *game) cameraPlayers() []*world.Player {
+	var living []*world.Player
+	for _, p := range g.visiblePlayers() {
+		if p.Health > 0 {
+			living = append(living, p)
+		}
+	}
+	if len(living) == 0 {
+		return g.visiblePlayers()
+	}
+	return living
+}
+
+// updateCamera centers the camera on the players it frames.
+func (g *game) updateCamera() {
+	players := g.cameraPlayers()
+	var x, y float64
+	for _, p := range players {
+		x += p.X
+		y += p.Y
+	}
+	g.camX, g.camY = x/float64(len(players)), y/float64(len(players))
+}
+
+// framingScale returns the zoom level at which all players the camera frames
+// are shown on the screen.
+func (g *game) framingScale() float64 {
+	const (
+		defaultScale = 2.0
+		minScale     = 0.75
+		margin       = 8.0 // Tiles shown around the outermost players
+	)
+
+	players := g.cameraPlayers()
+	if len(players) == 1 {
+		return defaultScale
+	}
+
+	minX, minY := players[0].X, players[0].Y
+	maxX, maxY := minX, minY
+	for _, p := range players[1:] {
+		minX, minY = math.Min(minX, p.X), math.Min(minY, p.Y)
+		maxX, maxY = math.Max(maxX, p.X), math.Max(maxY, p.Y)
+	}
+
+	w, h := g.tilePosition(maxX-minX+margin, maxY-minY+margin)
+	scale := math.Min(defaultScale, math.Min(float64(g.w)/w, float64(g.h)/h))
+	return math.Max(minScale, scale)
+}
+
 // tilePosition transforms X,Y coordinates into tile positions.
 func (g *game) tilePosition(x, y float64) (float64, float64) {
-	tileSize := float64(g.level.tileSize)
+	tileSize := float64(g.world.Level.TileSize)
 	return x * tileSize, y * tileSize
 }
 
 // renderSprite renders a sprite on the screen.
 func (g *game) renderSprite(x float64, y float64, offsetx float64, offsety float64, angle float64, geoScale float64, colorScale float64, alpha float64, sprite *ebiten.Image, target *ebiten.Image) int {
+	return g.renderTintedSprite(x, y, offsetx, offsety, angle, geoScale, colorScale, [3]float64{1, 1, 1}, alpha, sprite, target)
+}
+
+// renderTintedSprite renders a sprite on the screen, scaling each color
+// channel by the provided tint.
+func (g *game) renderTintedSprite(x float64, y float64, offsetx float64, offsety float64, angle float64, geoScale float64, colorScale float64, tint [3]float64, alpha float64, sprite *ebiten.Image, target *ebiten.Image) int {
 	if g.minLevelColorScale != -1 && colorScale < g.minLevelColorScale {
 		colorScale = g.minLevelColorScale
 	}
@@ -1133,7 +1075,7 @@ func (g *game) renderSprite(x float64, y float64, offsetx float64, offsety float
 
 	// Skip drawing off-screen tiles.
 	drawX, drawY := g.levelCoordinatesToScreen(x, y)
-	padding := float64(g.level.tileSize) * 2
+	padding := float64(g.world.Level.TileSize) * 2
 	if drawX+padding < 0 || drawY+padding < 0 || drawX > float64(g.w)+padding || drawY > float64(g.h)+padding {
 		return 0
 	}
@@ -1147,14 +1089,14 @@ func (g *game) renderSprite(x float64, y float64, offsetx float64, offsety float
 	// Move to current isometric position.
 	g.op.GeoM.Translate(x, y)
 	// Translate camera position.
-	px, py := g.tilePosition(g.player.x, g.player.y)
+	px, py := g.tilePosition(g.camX, g.camY)
 	g.op.GeoM.Translate(-px, -py)
 	// Zoom.
 	g.op.GeoM.Scale(g.camScale, g.camScale)
 	// Center.
 	g.op.GeoM.Translate(float64(g.w/2.0), float64(g.h/2.0))
 
-	g.op.ColorM.Scale(colorScale, colorScale, colorScale, alpha)
+	g.op.ColorM.Scale(colorScale*tint[0], colorScale*tint[1], colorScale*tint[2], alpha)
 
 	target.DrawImage(sprite, g.op)
 
@@ -1169,22 +1111,25 @@ func (g *game) levelColorScale(x, y float64) float64 {
 		return 1
 	}
 
+	// Players are lit by the brightest torch.
 	var v float64
-	if g.player.hasTorch {
-		v = colorScaleValue(x, y, g.player.x, g.player.y)
+	for _, p := range g.visiblePlayers() {
+		if p.HasTorch && (p.Health > 0 || !g.gameOverTime.IsZero()) {
+			v = math.Max(v, world.ColorScaleValue(x, y, p.X, p.Y))
+		}
 	}
 
-	t := g.level.Tile(int(x), int(y))
+	t := g.world.Level.Tile(int(x), int(y))
 	if t == nil {
 		return 0
 	}
 
-	tileV := t.colorScale
+	tileV := t.ColorScale
 
 	s := math.Min(1, v+tileV)
 
-	if t.forceColorScale != 0 {
-		return t.forceColorScale
+	if t.ForceColorScale != 0 {
+		return t.ForceColorScale
 	}
 
 	return s
@@ -1192,10 +1137,10 @@ func (g *game) levelColorScale(x, y float64) float64 {
 
 func (g *game) drawProjectiles(screen *ebiten.Image) int {
 	var drawn int
-	for _, p := range g.projectiles {
-		colorScale := p.colorScale
+	for _, p := range g.world.Projectiles {
+		colorScale := p.ColorScale
 		if colorScale == 1 {
-			colorScale = g.levelColorScale(p.x, p.y)
+			colorScale = g.levelColorScale(p.X, p.Y)
 		}
 
 		alpha := 1.0
@@ -1204,15 +1149,36 @@ func (g *game) drawProjectiles(screen *ebiten.Image) int {
 		}
 		// TODO if colorscale and gamewon, alpha is colorscale
 
-		drawn += g.renderSprite(p.x, p.y, 0, 0, p.angle, 1.0, colorScale, alpha, imageAtlas[ImageBullet], screen)
+		if p.Faction == world.FactionCreeps {
+			drawn += g.renderTintedSprite(p.X, p.Y, 0, 0, p.Angle, 1.0, 1.0, creepProjectileTint, alpha, imageAtlas[ImageBullet], screen)
+			continue
+		}
+
+		drawn += g.renderSprite(p.X, p.Y, 0, 0, p.Angle, 1.0, colorScale, alpha, imageAtlas[ImageBullet], screen)
 	}
 	return drawn
 }
 
-func (g *game) drawPlayer(screen *ebiten.Image) int {
+// drawPlayers draws each visible player.
+func (g *game) drawPlayers(screen *ebiten.Image) int {
 	var drawn int
+	for i, p := range g.visiblePlayers() {
+		drawn += g.drawPlayer(p, playerColors[i%maxPlayers], screen)
+	}
+	return drawn
+}
 
-	repelTime := g.player.garlicUntil.Sub(time.Now())
+// drawPlayer draws a player tinted using the provided color scale. Players who
+// died while other players are still alive are drawn faded.
+func (g *game) drawPlayer(p *world.Player, tint [3]float64, screen *ebiten.Image) int {
+	var drawn int
+
+	playerAlpha := 1.0
+	if p.Health <= 0 && g.gameOverTime.IsZero() {
+		playerAlpha = 0.3
+	}
+
+	repelTime := g.world.Clock.Until(p.GarlicUntil)
 	if repelTime > 0 && repelTime < 7*time.Second {
 		scale := repelTime.Seconds() + 1
 		offset := 12 * scale
@@ -1220,10 +1186,10 @@ func (g *game) drawPlayer(screen *ebiten.Image) int {
 		if repelTime.Seconds() < 3 {
 			alpha = repelTime.Seconds() / 12
 		}
-		drawn += g.renderSprite(g.player.x+0.25, g.player.y+0.25, -offset, -offset, 0, scale, 1.0, alpha, imageAtlas[ImageGarlic], screen)
+		drawn += g.renderSprite(p.X+0.25, p.Y+0.25, -offset, -offset, 0, scale, 1.0, alpha, imageAtlas[ImageGarlic], screen)
 	}
 
-	holyWaterTime := g.player.holyWaterUntil.Sub(time.Now())
+	holyWaterTime := g.world.Clock.Until(p.HolyWaterUntil)
 	if holyWaterTime > 0 && holyWaterTime < time.Second {
 		scale := (holyWaterTime.Seconds() + 1) * 2
 		offset := 16 * scale
@@ -1231,10 +1197,10 @@ func (g *game) drawPlayer(screen *ebiten.Image) int {
 		if holyWaterTime.Seconds() < 3 {
 			alpha = holyWaterTime.Seconds() / 2
 		}
-		drawn += g.renderSprite(g.player.x+0.25, g.player.y+0.25, -offset, -offset, 0, scale, 1.0, alpha, imageAtlas[ImageHolyWater], screen)
+		drawn += g.renderSprite(p.X+0.25, p.Y+0.25, -offset, -offset, 0, scale, 1.0, alpha, imageAtlas[ImageHolyWater], screen)
 	}
 
-	var playerColorScale = g.levelColorScale(g.player.x, g.player.y)
+	var playerColorScale = g.levelColorScale(p.X, p.Y)
 	if g.minPlayerColorScale != -1 {
 		playerColorScale = g.minPlayerColorScale
 	}
@@ -1242,30 +1208,30 @@ func (g *game) drawPlayer(screen *ebiten.Image) int {
 	var weaponSprite *ebiten.Image
 
 	playerSprite := playerSS.Frame1
-	playerAngle := g.player.angle
+	playerAngle := p.Angle
 	mul := float64(1)
-	if g.player.weapon != nil {
-		weaponSprite = g.player.weapon.spriteFlipped
+	if p.Weapon != nil {
+		weaponSprite = g.weaponSpriteFlipped
 	}
-	if (g.player.angle > math.Pi/2 || g.player.angle < -1*math.Pi/2) && (g.gameOverTime.IsZero() || time.Since(g.gameOverTime) < 7*time.Second) {
+	if (p.Angle > math.Pi/2 || p.Angle < -1*math.Pi/2) && (g.gameOverTime.IsZero() || g.world.Clock.Since(g.gameOverTime) < 7*time.Second) {
 		playerSprite = playerSS.Frame2
 		playerAngle = playerAngle - math.Pi
 		mul = -1
-		if g.player.weapon != nil {
-			weaponSprite = g.player.weapon.sprite
+		if p.Weapon != nil {
+			weaponSprite = g.weaponSprite
 		}
 	}
-	drawn += g.renderSprite(g.player.x, g.player.y, 0, 0, playerAngle, 1.0, playerColorScale, 1.0, playerSprite, screen)
-	if g.player.weapon != nil {
-		drawn += g.renderSprite(g.player.x, g.player.y, 11*mul, 9, playerAngle, 1.0, playerColorScale, 1.0, weaponSprite, screen)
+	drawn += g.renderTintedSprite(p.X, p.Y, 0, 0, playerAngle, 1.0, playerColorScale, tint, playerAlpha, playerSprite, screen)
+	if p.Weapon != nil {
+		drawn += g.renderSprite(p.X, p.Y, 11*mul, 9, playerAngle, 1.0, playerColorScale, playerAlpha, weaponSprite, screen)
 	}
-	if g.player.hasTorch {
-		drawn += g.renderSprite(g.player.x, g.player.y, -10*mul, 2, playerAngle, 1.0, playerColorScale, 1.0, sandstoneSS.TorchMulti, screen)
+	if p.HasTorch {
+		drawn += g.renderSprite(p.X, p.Y, -10*mul, 2, playerAngle, 1.0, playerColorScale, playerAlpha, sandstoneSS.TorchMulti, screen)
 	}
 
 	flashDuration := 40 * time.Millisecond
-	if g.player.weapon != nil && time.Since(g.player.weapon.lastFire) < flashDuration {
-		drawn += g.renderSprite(g.player.x, g.player.y, 39, -1, g.player.angle, 1.0, playerColorScale, 1.0, imageAtlas[ImageMuzzleFlash], screen)
+	if p.Weapon != nil && g.world.Clock.Since(p.Weapon.LastFire) < flashDuration {
+		drawn += g.renderSprite(p.X, p.Y, 39, -1, p.Angle, 1.0, playerColorScale, 1.0, imageAtlas[ImageMuzzleFlash], screen)
 	}
 
 	return drawn
@@ -1276,48 +1242,62 @@ func (g *game) renderLevel(screen *ebiten.Image) int {
 	var drawn int
 
 	drawCreeps := func() {
-		for _, c := range g.level.creeps {
-			if c.health == 0 && c.creepType != TypeTorch {
+		for _, c := range g.world.Level.Creeps {
+			def := c.Definition()
+			if c.Health == 0 && !def.Static && !c.Dying() {
 				continue
 			}
 
-			a := 1.0
-			if c.creepType == TypeSoul {
-				a = 0.3
+			colorScale := g.levelColorScale(c.X, c.Y)
+			tint := [3]float64{1, 1, 1}
+			if def.Tint != [3]float64{} {
+				tint = def.Tint
 			}
 
-			drawn += g.renderSprite(c.x, c.y, 0, 0, c.angle, 1.0, g.levelColorScale(c.x, c.y), a, c.sprites[c.frame], screen)
-			if c.frames > 1 && time.Since(c.lastFrame) >= 75*time.Millisecond {
-				c.frame++
-				if c.frame == c.frames {
-					c.frame = 0
-				}
-				c.lastFrame = time.Now()
+			// Redden creeps which have eaten souls.
+			for i := 0; i < c.SoulsEaten; i++ {
+				tint[1] *= fedTint
+				tint[2] *= fedTint
 			}
+
+			// Flash after being hit.
+			if g.world.Clock.Since(c.LastHit) < time.Duration(def.HitFlashTicks)*world.TickDuration {
+				colorScale = 1
+				tint = hitFlashTint
+			}
+
+			if def.Boss {
+				drawn += g.renderTintedSprite(c.X, c.Y, -16, -16, c.Angle, bossScale, colorScale, tint, def.Alpha, g.creepSprite(c), screen)
+				continue
+			}
+			drawn += g.renderTintedSprite(c.X, c.Y, 0, 0, c.Angle, 1.0, colorScale, tint, def.Alpha, g.creepSprite(c), screen)
 		}
 	}
 
 	// Render top tiles.
-	var t *Tile
-	for y := 0; y < g.level.h; y++ {
-		for x := 0; x < g.level.w; x++ {
-			t = g.level.tiles[y][x]
+	var t *world.Tile
+	for y := 0; y < g.world.Level.H; y++ {
+		for x := 0; x < g.world.Level.W; x++ {
+			t = g.world.Level.Tiles[y][x]
 			if t == nil {
 				continue // No tile at this position.
 			}
 
-			for i := range t.sprites {
-				drawn += g.renderSprite(float64(x), float64(y), 0, 0, 0, 1.0, g.levelColorScale(float64(x), float64(y)), 1.0, t.sprites[i], screen)
+			for i := range t.Sprites {
+				drawn += g.renderSprite(float64(x), float64(y), 0, 0, 0, 1.0, g.levelColorScale(float64(x), float64(y)), 1.0, spriteAtlas[t.Sprites[i]], screen)
+			}
+			for i := range t.Blood {
+				drawn += g.renderSprite(float64(x), float64(y), 0, 0, 0, 1.0, g.levelColorScale(float64(x), float64(y)), 1.0, g.bloodSprite(t.Blood[i]), screen)
 			}
 		}
 	}
 
-	for _, item := range g.level.items {
-		if item.health == 0 {
+	for _, item := range g.world.Level.Items {
+		if item.Health == 0 {
 			continue
 		}
 
-		drawn += g.renderSprite(item.x, item.y, 0, 0, 0, 1.0, g.levelColorScale(item.x, item.y), 1.0, item.sprite, screen)
+		drawn += g.renderSprite(item.X, item.Y, 0, 0, 0, 1.0, g.levelColorScale(item.X, item.Y), 1.0, itemSprite(item), screen)
 	}
 
 	if !g.gameWon {
@@ -1328,17 +1308,17 @@ func (g *game) renderLevel(screen *ebiten.Image) int {
 		drawn += g.drawProjectiles(screen)
 	}
 
-	drawn += g.drawPlayer(screen)
+	drawn += g.drawPlayers(screen)
 
 	if g.gameWon {
 		drawCreeps()
 	}
 
 	// Render side and bottom walls a second time.
-	if g.level.sideWalls != nil {
-		for y := 0; y < g.level.h; y++ {
-			for x := 0; x < g.level.w; x++ {
-				t = g.level.sideWalls[y][x]
+	if g.world.Level.SideWalls != nil {
+		for y := 0; y < g.world.Level.H; y++ {
+			for x := 0; x < g.world.Level.W; x++ {
+				t = g.world.Level.SideWalls[y][x]
 				if t == nil {
 					continue // No tile at this position.
 				}
@@ -1347,20 +1327,20 @@ func (g *game) renderLevel(screen *ebiten.Image) int {
 			}
 		}
 	}
-	if g.level.otherWalls != nil {
-		for y := 0; y < g.level.h; y++ {
-			for x := 0; x < g.level.w; x++ {
-				t = g.level.otherWalls[y][x]
+	if g.world.Level.OtherWalls != nil {
+		for y := 0; y < g.world.Level.H; y++ {
+			for x := 0; x < g.world.Level.W; x++ {
+				t = g.world.Level.OtherWalls[y][x]
 				if t == nil {
-					t = g.level.tiles[y][x]
-					if t == nil || len(t.sprites) == 0 {
+					t = g.world.Level.Tiles[y][x]
+					if t == nil || len(t.Sprites) == 0 {
 						drawn += g.renderSprite(float64(x), float64(y), 0, 0, 0, 1.0, 1.0, 1.0, blackSquare, screen)
 					}
 					continue // No tile at this position.
 				}
 
-				for i := range t.sprites {
-					drawn += g.renderSprite(float64(x), float64(y), 0, 0, 0, 1.0, g.levelColorScale(float64(x), float64(y)), 1.0, t.sprites[i], screen)
+				for i := range t.Sprites {
+					drawn += g.renderSprite(float64(x), float64(y), 0, 0, 0, 1.0, g.levelColorScale(float64(x), float64(y)), 1.0, spriteAtlas[t.Sprites[i]], screen)
 				}
 			}
 		}
@@ -1369,15 +1349,6 @@ func (g *game) renderLevel(screen *ebiten.Image) int {
 	return drawn
 }
 
-func (g *game) resetExpiredTimers() {
-	if !g.player.garlicUntil.IsZero() && g.player.garlicUntil.Sub(time.Now()) <= 0 {
-		g.player.garlicUntil = time.Time{}
-	}
-	if !g.player.holyWaterUntil.IsZero() && g.player.holyWaterUntil.Sub(time.Now()) <= 0 {
-		g.player.holyWaterUntil = time.Time{}
-	}
-}
-
 func (g *game) playSound(sound int, volume float64) error {
 	if g.muteAudio {
 		return nil
@@ -1390,121 +1361,53 @@ func (g *game) playSound(sound int, volume float64) error {
 	}
 	player.Pause()
 	player.Rewind()
-	player.SetVolume(volume)
+	player.SetVolume(volume * g.settings.MasterVolume * g.settings.SFXVolume)
 	player.Play()
 	return nil
 }
 
-func (g *game) hurtCreep(c *gameCreep, damage int) error {
-	if damage == -1 {
-		c.health = 0
-		return nil
-	}
-
-	c.health -= damage
-	if c.health > 0 {
-		return nil
-	}
-
-	// Killed creep.
-	g.player.score += c.killScore() * g.levelNum
-
-	if c.creepType == TypeTorch {
-		// TODO play break sound
-		c.frames = 1
-		c.frame = 0
-		c.sprites = []*ebiten.Image{
-			sandstoneSS.TorchTop9,
-		}
-		g.level.bakePartialLightmap(int(c.x), int(c.y))
-		return nil
-	}
-
-	// Play vampire die sound.
-
-	var volume float64
-	var dieSound int
-
-	dieSound = SoundVampireDie1
-	if rand.Intn(2) == 1 {
-		dieSound = SoundVampireDie2
-	}
-	volume = vampireDieVolume
-	/*
-		if c.creepType == TypeBat {
-			dieSound = SoundBat
-			volume = batVolume
-		} else {
-			dieSound = SoundVampireDie1
-			if rand.Intn(2) == 1 {
-				dieSound = SoundVampireDie2
-			}
-			volume = vampireDieVolume
-		}
-	*/
-
-	dx, dy := deltaXY(g.player.x, g.player.y, c.x, c.y)
-	distance := dx
-	if dy > dx {
-		distance = dy
-	}
-	if distance > 9 {
-		volume *= 0.7
-	} else if distance > 6 {
-		volume *= 0.85
-	}
-
-	err := g.playSound(dieSound, volume)
-	if err != nil {
-		return err
-	}
-
-	g.addBloodSplatter(c.x, c.y)
-
-	soul := g.level.addCreep(TypeSoul)
-	soul.x, soul.y = c.x, c.y
-	soul.moveX, soul.moveY = c.moveX/4, c.moveY/4
-	soul.tick, soul.nextAction = c.tick, c.nextAction
-
-	return nil
-}
-
 func (g *game) levelCoordinatesToScreen(x, y float64) (float64, float64) {
-	px, py := g.tilePosition(g.player.x, g.player.y)
+	px, py := g.tilePosition(g.camX, g.camY)
 	py *= -1
 	return ((x - px) * g.camScale) + float64(g.w/2.0), ((y + py) * g.camScale) + float64(g.h/2.0)
 }
 
-func (g *game) addBloodSplatter(x, y float64) {
-	splatterSprite := ebiten.NewImage(32, 32)
+// bloodSprite returns the blood splatter sprite generated from the provided
+// seed.
+func (g *game) bloodSprite(seed int64) *ebiten.Image {
+	splatterSprite, ok := g.bloodSprites[seed]
+	if ok {
+		return splatterSprite
+	}
+
+	r := rand.New(rand.NewSource(seed))
+	splatterSprite = ebiten.NewImage(32, 32)
 
 	for y := 8; y < 20; y++ {
-		if rand.Intn(2) != 0 {
+		if r.Intn(2) != 0 {
 			continue
 		}
 		for x := 12; x < 20; x++ {
-			if rand.Intn(5) != 0 {
+			if r.Intn(5) != 0 {
 				continue
 			}
 			splatterSprite.Set(x, y, colornames.Red)
 		}
 	}
 	for y := 2; y < 26; y++ {
-		if rand.Intn(5) != 0 {
+		if r.Intn(5) != 0 {
 			continue
 		}
 		for x := 2; x < 26; x++ {
-			if rand.Intn(12) != 0 {
+			if r.Intn(12) != 0 {
 				continue
 			}
 			splatterSprite.Set(x, y, colornames.Red)
 		}
 	}
 
-	t := g.level.Tile(int(x), int(y))
-	if t != nil {
-		t.AddSprite(splatterSprite)
-	}
+	g.bloodSprites[seed] = splatterSprite
+	return splatterSprite
 }
 
 func (g *game) showWinScreen() {
@@ -1515,15 +1418,19 @@ func (g *game) showWinScreen() {
 	g.minLevelColorScale = 0.4
 
 	g.gameWon = true
-	g.gameOverTime = time.Now()
+	g.gameOverTime = g.world.Clock.Now()
+
+	g.saveReplay()
 
 	g.updateCursor()
 
-	g.player.health = 0
-	g.player.garlicUntil = time.Time{}
-	g.player.holyWaterUntil = time.Time{}
+	for _, p := range g.world.Players {
+		p.Health = 0
+		p.GarlicUntil = time.Time{}
+		p.HolyWaterUntil = time.Time{}
+	}
 
-	g.level = newWinLevel(g.player)
+	g.world.Level = world.NewWinLevel(g.world.Players[0], rand.New(rand.NewSource(g.world.Seed)), g.world.Clock)
 
 	g.winScreenBackground = ebiten.NewImage(g.w, g.h)
 	g.winScreenBackground.Fill(colornames.Deepskyblue)
@@ -1535,31 +1442,31 @@ func (g *game) showWinScreen() {
 	g.winScreenSunY = float64(g.h/2) + float64(sunSize/2)
 
 	go func() {
-		p := g.player
-		l := g.level
+		p := g.world.Players[0]
+		l := g.world.Level
 
-		var stars []*projectile
+		var stars []*world.Projectile
 
 		addStar := func() {
-			star := &projectile{
-				x:          p.x + (0.5-rand.Float64())*66,
-				y:          p.y + (0.5-rand.Float64())*66,
-				colorScale: rand.Float64(),
+			star := &world.Projectile{
+				X:          p.X + (0.5-rand.Float64())*66,
+				Y:          p.Y + (0.5-rand.Float64())*66,
+				ColorScale: rand.Float64(),
 			}
-			g.projectiles = append(g.projectiles, star)
+			g.world.Projectiles = append(g.world.Projectiles, star)
 			stars = append(stars, star)
 		}
 
-		lastPlayerX := p.x
+		lastPlayerX := p.X
 		updateStars := func() {
-			if p.x == lastPlayerX {
+			if p.X == lastPlayerX {
 				return
 			}
 
 			for _, star := range stars {
-				star.x = p.x - (lastPlayerX - star.x)
+				star.X = p.X - (lastPlayerX - star.X)
 			}
-			lastPlayerX = p.x
+			lastPlayerX = p.X
 		}
 
 		// Add stars.
@@ -1570,32 +1477,30 @@ func (g *game) showWinScreen() {
 
 		// Walk away.
 		for i := 0; i < 36; i++ {
-			p.x += 0.05
+			p.X += 0.05
 			updateStars()
 			time.Sleep(time.Second / 144)
 		}
 		for i := 0; i < 288; i++ {
-			p.x += 0.05 * (float64(288-i) / 288)
+			p.X += 0.05 * (float64(288-i) / 288)
 			updateStars()
 
 			time.Sleep(time.Second / 144)
 		}
 
 		// Turn around.
-		p.angle = math.Pi
+		p.Angle = math.Pi
 		time.Sleep(time.Millisecond * 1750)
 
 		// Throw weapon.
-		weaponSprite := newCreep(TypeTorch, l, p)
-		weaponSprite.x, weaponSprite.y = p.x, p.y
-		weaponSprite.frames = 1
-		weaponSprite.frame = 0
-		weaponSprite.sprites = []*ebiten.Image{
-			imageAtlas[ImageUzi],
-		}
+		weaponSprite := world.NewCreep(world.TypeTorch, l)
+		weaponSprite.X, weaponSprite.Y = p.X, p.Y
+		weaponSprite.Frames = 1
+		weaponSprite.Frame = 0
+		g.propSprites[weaponSprite] = imageAtlas[ImageUzi]
 
-		p.weapon = nil
-		l.creeps = append(l.creeps, weaponSprite)
+		p.Weapon = nil
+		l.Creeps = append(l.Creeps, weaponSprite)
 
 		startX := 108
 
@@ -1603,22 +1508,22 @@ func (g *game) showWinScreen() {
 
 		go func() {
 			for i := 0; i < 144*2; i++ {
-				if weaponSprite.x < doorX {
-					for i, c := range l.creeps {
+				if weaponSprite.X < doorX {
+					for i, c := range l.Creeps {
 						if c == weaponSprite {
-							l.creeps = append(l.creeps[:i], l.creeps[i+1:]...)
+							l.Creeps = append(l.Creeps[:i], l.Creeps[i+1:]...)
 						}
 					}
 					return
 				}
 
-				weaponSprite.x -= 0.05
+				weaponSprite.X -= 0.05
 				if i < 100 {
-					weaponSprite.y -= 0.005 * (float64(144-i) / 144)
+					weaponSprite.Y -= 0.005 * (float64(144-i) / 144)
 				} else {
-					weaponSprite.y += 0.01 * (float64(288-i) / 288)
+					weaponSprite.Y += 0.01 * (float64(288-i) / 288)
 				}
-				weaponSprite.angle -= .1
+				weaponSprite.Angle -= .1
 				time.Sleep(time.Second / 144)
 			}
 		}()
@@ -1626,45 +1531,43 @@ func (g *game) showWinScreen() {
 		time.Sleep(time.Second / 2)
 
 		// Throw torch.
-		torchSprite := newCreep(TypeTorch, l, p)
-		torchSprite.x, torchSprite.y = p.x, p.y
-		torchSprite.frames = 1
-		torchSprite.frame = 0
-		torchSprite.sprites = []*ebiten.Image{
-			sandstoneSS.TorchMulti,
-		}
+		torchSprite := world.NewCreep(world.TypeTorch, l)
+		torchSprite.X, torchSprite.Y = p.X, p.Y
+		torchSprite.Frames = 1
+		torchSprite.Frame = 0
+		g.propSprites[torchSprite] = sandstoneSS.TorchMulti
 
-		p.hasTorch = false
-		l.creeps = append(l.creeps, torchSprite)
-		l.torches = append(l.torches, torchSprite)
-		l.bakePartialLightmap(int(torchSprite.x), int(torchSprite.y))
+		p.HasTorch = false
+		l.Creeps = append(l.Creeps, torchSprite)
+		l.Torches = append(l.Torches, torchSprite)
+		l.BakePartialLightmap(int(torchSprite.X), int(torchSprite.Y))
 
 		go func() {
-			lastTorchX := torchSprite.x
+			lastTorchX := torchSprite.X
 
 			for i := 0; i < 144*3; i++ {
-				if torchSprite.x < doorX {
-					for i, c := range l.creeps {
+				if torchSprite.X < doorX {
+					for i, c := range l.Creeps {
 						if c == torchSprite {
-							l.creeps = append(l.creeps[:i], l.creeps[i+1:]...)
-							l.torches = nil
+							l.Creeps = append(l.Creeps[:i], l.Creeps[i+1:]...)
+							l.Torches = nil
 						}
 					}
 				}
 
-				torchSprite.x -= 0.05
+				torchSprite.X -= 0.05
 				if i < 100 {
-					torchSprite.y -= 0.005 * (float64(144-i) / 144)
+					torchSprite.Y -= 0.005 * (float64(144-i) / 144)
 				} else {
-					torchSprite.y += 0.01 * (float64(288-i) / 288)
+					torchSprite.Y += 0.01 * (float64(288-i) / 288)
 				}
 
-				if lastTorchX-torchSprite.x >= 0.1 {
-					l.bakePartialLightmap(int(torchSprite.x), int(torchSprite.y))
-					lastTorchX = torchSprite.x
+				if lastTorchX-torchSprite.X >= 0.1 {
+					l.BakePartialLightmap(int(torchSprite.X), int(torchSprite.Y))
+					lastTorchX = torchSprite.X
 				}
 
-				torchSprite.angle -= .1
+				torchSprite.Angle -= .1
 				time.Sleep(time.Second / 144)
 			}
 		}()
@@ -1672,14 +1575,14 @@ func (g *game) showWinScreen() {
 		// Walk away.
 		time.Sleep(time.Second)
 
-		p.angle = 0
+		p.Angle = 0
 		for i := 0; i < 144; i++ {
-			p.x += 0.05 * (float64(i) / 144)
+			p.X += 0.05 * (float64(i) / 144)
 			// Fade out stars.
 			for _, star := range stars {
-				star.colorScale -= 0.01
-				if star.colorScale < 0 {
-					star.colorScale = 0
+				star.ColorScale -= 0.01
+				if star.ColorScale < 0 {
+					star.ColorScale = 0
 				}
 			}
 			updateStars()
@@ -1689,7 +1592,7 @@ func (g *game) showWinScreen() {
 		var removedExistingStars bool
 
 		for i := 0; i < 144*25; i++ {
-			if p.health > 0 {
+			if g.world.Alive() {
 				// Game has restarted.
 				return
 			}
@@ -1698,33 +1601,33 @@ func (g *game) showWinScreen() {
 				if !removedExistingStars {
 					// Remove existing stars.
 					stars = nil
-					g.projectiles = nil
+					g.world.Projectiles = nil
 
 					removedExistingStars = true
 				}
 			}
 
 			if i > 144*12 {
-				p.angle -= 0.0025 * (float64(i-(144*12)) / (144 * 3))
+				p.Angle -= 0.0025 * (float64(i-(144*12)) / (144 * 3))
 
 				for j := 0; j < 6; j++ {
 					addStar()
 				}
 			}
 
-			p.x += 0.05
+			p.X += 0.05
 			updateStars()
 
 			if i > 144*11 {
 				for _, star := range stars {
 					pct := float64((144*15)-i) / 144 * 7
 
-					star.x -= 0.1 * pct / 50
+					star.X -= 0.1 * pct / 50
 
 					// Apply warp effect.
 					div := 100.0
-					dx, dy := deltaXY(g.player.x, g.player.y, star.x, star.y)
-					star.x, star.y = star.x-(dx/100)*pct/div-0.025, star.y+(dy/100)*pct/div-0.01
+					dx, dy := world.DeltaXY(p.X, p.Y, star.X, star.Y)
+					star.X, star.Y = star.X-(dx/100)*pct/div-0.025, star.Y+(dy/100)*pct/div-0.01
 				}
 			}
 
@@ -1771,9 +1674,9 @@ func (g *game) showWinScreen() {
 		}
 
 		g.Lock()
-		for y := 0; y < g.level.h; y++ {
-			for x := 0; x < g.level.w; x++ {
-				g.level.tiles[y][x].sprites = nil
+		for y := 0; y < g.world.Level.H; y++ {
+			for x := 0; x < g.world.Level.W; x++ {
+				g.world.Level.Tiles[y][x].Sprites = nil
 			}
 		}
 		g.Unlock()
@@ -1791,7 +1694,7 @@ func (g *game) showWinScreen() {
 		}()
 
 		for i := 0.01; i < 1; i *= 1.02 {
-			if g.player.health > 0 {
+			if g.world.Alive() {
 				return
 			}
 			if i <= 1 {
@@ -1803,16 +1706,11 @@ func (g *game) showWinScreen() {
 }
 
 func (g *game) exit() {
-	os.Exit(0)
-}
+	g.saveWindowSize()
 
-func deltaXY(x1, y1, x2, y2 float64) (dx float64, dy float64) {
-	dx, dy = x1-x2, y1-y2
-	if dx < 0 {
-		dx *= -1
+	if !g.gameStartTime.IsZero() && g.gameOverTime.IsZero() {
+		g.saveReplay()
+		g.autoSave()
 	}
-	if dy < 0 {
-		dy *= -1
-	}
-	return dx, dy
+	os.Exit(0)
 }
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+
+	"code.rocketnine.space/tslocum/carotidartillery/world"
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/audio"
+)
+
+func TestApplyLevelTheme(t *testing.T) {
+	originalThemes, originalAtlas := levelThemes, spriteAtlas
+	defer func() {
+		levelThemes, spriteAtlas = originalThemes, originalAtlas
+	}()
+
+	tiles := &EnvironmentSpriteSheet{FloorA: ebiten.NewImage(32, 32)}
+	levelThemes = map[string]*EnvironmentSpriteSheet{"test": tiles}
+
+	def := *world.LevelDefinitions[0]
+	def.Theme = "test"
+	def.Music = "gunshot"
+	original := world.LevelDefinitions[0]
+	world.LevelDefinitions[0] = &def
+	defer func() {
+		world.LevelDefinitions[0] = original
+	}()
+
+	err := checkLevelMusic()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := world.NewWorld()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = w.Reset(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := &game{
+		world:        w,
+		audioContext: audio.NewContext(sampleRate),
+		settings:     defaultSettings(),
+	}
+	err = g.applyLevelTheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spriteAtlas[world.SpriteFloorA] != tiles.FloorA {
+		t.Error("level theme was not applied")
+	}
+	if g.music == nil || g.musicName != def.Music {
+		t.Error("level music was not played")
+	}
+
+	def.Music = "unknown"
+	if checkLevelMusic() == nil {
+		t.Error("unknown level music was accepted")
+	}
+}
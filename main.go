@@ -9,6 +9,7 @@ import (
 	"syscall"
 	"time"
 
+	"code.rocketnine.space/tslocum/carotidartillery/world"
 	"github.com/hajimehoshi/ebiten/v2"
 )
 
@@ -17,8 +18,7 @@ func main() {
 
 	ebiten.SetWindowTitle("Carotid Artillery")
 	ebiten.SetWindowResizable(true)
-	ebiten.SetFullscreen(true)
-	ebiten.SetMaxTPS(144)
+	ebiten.SetMaxTPS(world.TPS)
 	ebiten.SetRunnableOnUnfocused(true) // Note - this currently does nothing in ebiten
 	ebiten.SetWindowClosingHandled(true)
 	ebiten.SetFPSMode(ebiten.FPSModeVsyncOn)
@@ -37,28 +37,25 @@ func main() {
 	go func() {
 		<-sigc
 
+		g.Lock()
 		g.exit()
 	}()
 
-	// Handle warp.
-	if g.levelNum > 0 {
-		warpTo := g.levelNum
-		go func() {
-			time.Sleep(2 * time.Second)
-			g.Lock()
-			defer g.Unlock()
-
-			g.reset()
-			g.levelNum = warpTo - 1
-			g.nextLevel()
-		}()
+	if g.replayFile != "" {
+		g.replay, err = loadReplay(g.replayFile)
+		if err != nil {
+			log.Fatal(err)
+		}
 	}
 
+	g.hasSave = saveExists(quickSaveFile)
+	g.hasAutoSave = saveExists(autoSaveFile)
+
 	err = g.reset()
 	if err != nil {
 		panic(err)
 	}
-	if !g.debugMode {
+	if !g.debugMode && g.replay == nil {
 		g.gameStartTime = time.Time{}
 	}
 
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// menuItem is a selectable option within a menu.
+type menuItem struct {
+	label  func() string
+	action func() error
+
+	// adjust changes the value of an option in the provided direction (-1 or
+	// 1). It is called when the option is selected if action is nil.
+	adjust func(dir int)
+}
+
+// menu is a list of options shown while the game is paused.
+type menu struct {
+	title    string
+	items    []*menuItem
+	selected int
+	parent   *menu // Menu shown when going back
+}
+
+func staticLabel(label string) func() string {
+	return func() string {
+		return label
+	}
+}
+
+// pause freezes the simulation and shows the pause menu.
+func (g *game) pause() {
+	if g.menu != nil {
+		return
+	}
+	g.menu = g.pauseMenu()
+}
+
+// resume hides the pause menu and continues the simulation.
+func (g *game) resume() {
+	g.menu = nil
+}
+
+func (g *game) pauseMenu() *menu {
+	m := &menu{
+		title: "PAUSED",
+	}
+	m.items = []*menuItem{
+		{
+			label: staticLabel("RESUME"),
+			action: func() error {
+				g.resume()
+				return nil
+			},
+		},
+	}
+	// The win screen is animated outside of the simulation and may not be
+	// interrupted.
+	if !g.gameWon {
+		m.items = append(m.items, &menuItem{
+			label: staticLabel("RESTART"),
+			action: func() error {
+				g.resume()
+				g.replay = nil
+				return g.reset()
+			},
+		})
+	}
+	m.items = append(m.items, &menuItem{
+		label: staticLabel("SETTINGS"),
+		action: func() error {
+			g.menu = g.settingsMenu(m)
+			return nil
+		},
+	})
+	if !g.disableQuit {
+		m.items = append(m.items, &menuItem{
+			label: staticLabel("QUIT"),
+			action: func() error {
+				g.menu = g.quitMenu(m)
+				return nil
+			},
+		})
+	}
+	return m
+}
+
+func (g *game) settingsMenu(parent *menu) *menu {
+	onOff := func(v bool) string {
+		if v {
+			return "ON"
+		}
+		return "OFF"
+	}
+	percent := func(v float64) string {
+		return fmt.Sprintf("%d%%", int(math.Round(v*100)))
+	}
+	// step adjusts a value in increments, limiting it to the provided range.
+	step := func(v float64, dir int, increment float64, min float64, max float64) float64 {
+		v = math.Round((v+float64(dir)*increment)/increment) * increment
+		return math.Max(min, math.Min(max, v))
+	}
+	return &menu{
+		title: "SETTINGS",
+		items: []*menuItem{
+			{
+				label: func() string {
+					return "FULLSCREEN: " + onOff(ebiten.IsFullscreen())
+				},
+				adjust: func(dir int) {
+					g.setFullscreen(!ebiten.IsFullscreen())
+				},
+			}, {
+				label: func() string {
+					return fmt.Sprintf("WINDOW SIZE: %dX%d", g.settings.WindowWidth, g.settings.WindowHeight)
+				},
+				adjust: func(dir int) {
+					i := -1
+					for j, size := range windowSizes {
+						if size[0] == g.settings.WindowWidth && size[1] == g.settings.WindowHeight {
+							i = j
+							break
+						}
+					}
+					i += dir
+					if i < 0 {
+						i = len(windowSizes) - 1
+					} else if i >= len(windowSizes) {
+						i = 0
+					}
+					g.settings.WindowWidth, g.settings.WindowHeight = windowSizes[i][0], windowSizes[i][1]
+					ebiten.SetWindowSize(g.settings.WindowWidth, g.settings.WindowHeight)
+					g.saveSettings()
+				},
+			}, {
+				label: func() string {
+					return "MASTER VOLUME: " + percent(g.settings.MasterVolume)
+				},
+				adjust: func(dir int) {
+					g.settings.MasterVolume = step(g.settings.MasterVolume, dir, 0.1, 0, 1)
+					g.applyMusicVolume()
+					g.saveSettings()
+				},
+			}, {
+				label: func() string {
+					return "SFX VOLUME: " + percent(g.settings.SFXVolume)
+				},
+				adjust: func(dir int) {
+					g.settings.SFXVolume = step(g.settings.SFXVolume, dir, 0.1, 0, 1)
+					g.saveSettings()
+				},
+			}, {
+				label: func() string {
+					return "AUDIO: " + onOff(!g.muteAudio)
+				},
+				adjust: func(dir int) {
+					g.setMuteAudio(!g.muteAudio)
+				},
+			}, {
+				label: func() string {
+					return "GAMEPAD DEADZONE: " + percent(g.settings.GamepadDeadZone)
+				},
+				adjust: func(dir int) {
+					g.settings.GamepadDeadZone = step(g.settings.GamepadDeadZone, dir, 0.05, 0.05, 0.5)
+					g.saveSettings()
+				},
+			}, {
+				label: func() string {
+					return "DEBUG OVERLAY: " + onOff(g.settings.DebugOverlay)
+				},
+				adjust: func(dir int) {
+					g.setDebugOverlay(!g.settings.DebugOverlay)
+				},
+			}, {
+				label: staticLabel("CONTROLS"),
+				action: func() error {
+					g.menu = g.controlsMenu(g.menu)
+					return nil
+				},
+			}, {
+				label: staticLabel("BACK"),
+				action: func() error {
+					g.menu = parent
+					return nil
+				},
+			},
+		},
+		parent: parent,
+	}
+}
+
+func (g *game) quitMenu(parent *menu) *menu {
+	return &menu{
+		title: "QUIT GAME?",
+		items: []*menuItem{
+			{
+				label: staticLabel("NO"),
+				action: func() error {
+					g.menu = parent
+					return nil
+				},
+			}, {
+				label: staticLabel("YES"),
+				action: func() error {
+					g.exit()
+					return nil
+				},
+			},
+		},
+		parent: parent,
+	}
+}
+
+// rebindableActions are the actions which may be rebound in the controls menu.
+var rebindableActions = []struct {
+	label  string
+	action action
+	sign   float64
+}{
+	{"MOVE LEFT", actionMoveX, -1},
+	{"MOVE RIGHT", actionMoveX, 1},
+	{"MOVE UP", actionMoveY, -1},
+	{"MOVE DOWN", actionMoveY, 1},
+	{"AIM LEFT", actionAimX, -1},
+	{"AIM RIGHT", actionAimX, 1},
+	{"AIM UP", actionAimY, -1},
+	{"AIM DOWN", actionAimY, 1},
+	{"FIRE", actionFire, 1},
+	{"WALK", actionWalk, 1},
+	{"PAUSE", actionPause, 1},
+	{"JOIN", actionJoin, 1},
+	{"MUTE", actionMute, 1},
+	{"QUICKSAVE", actionQuickSave, 1},
+	{"QUICKLOAD", actionQuickLoad, 1},
+}
+
+// rebindTarget is an action being rebound.
+type rebindTarget struct {
+	action action
+	sign   float64
+}
+
+func (g *game) controlsMenu(parent *menu) *menu {
+	m := &menu{
+		title:  "CONTROLS",
+		parent: parent,
+	}
+	for _, r := range rebindableActions {
+		r := r
+		target := &rebindTarget{r.action, r.sign}
+		m.items = append(m.items, &menuItem{
+			label: func() string {
+				if g.rebinding != nil && *g.rebinding == *target {
+					return r.label + ": PRESS ANY INPUT"
+				}
+				return r.label + ": " + g.actionLabel(r.action, r.sign)
+			},
+			action: func() error {
+				g.rebinding = target
+				return nil
+			},
+		})
+	}
+	m.items = append(m.items, &menuItem{
+		label: staticLabel("RESET TO DEFAULTS"),
+		action: func() error {
+			g.controls = defaultControls()
+			g.saveControls()
+			return nil
+		},
+	}, &menuItem{
+		label: staticLabel("BACK"),
+		action: func() error {
+			g.menu = parent
+			return nil
+		},
+	})
+	return m
+}
+
+// updateMenu handles input while the game is paused.
+func (g *game) updateMenu() error {
+	m := g.menu
+
+	if g.rebinding != nil {
+		// Escape always cancels rebinding, so it may not be bound.
+		if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+			g.rebinding = nil
+			return nil
+		}
+
+		b := g.pressedBinding()
+		if b != nil {
+			g.rebind(g.rebinding.action, g.rebinding.sign, b)
+			g.rebinding = nil
+		}
+		return nil
+	}
+
+	switch {
+	case g.actionJustPressed(actionBack) || g.actionJustPressed(actionPause):
+		if m.parent != nil {
+			g.menu = m.parent
+		} else {
+			g.resume()
+		}
+	case g.actionJustPressed(actionMenuUp):
+		m.selected--
+		if m.selected < 0 {
+			m.selected = len(m.items) - 1
+		}
+	case g.actionJustPressed(actionMenuDown):
+		m.selected++
+		if m.selected == len(m.items) {
+			m.selected = 0
+		}
+	case g.actionJustPressed(actionMenuLeft):
+		if item := m.items[m.selected]; item.adjust != nil {
+			item.adjust(-1)
+		}
+	case g.actionJustPressed(actionMenuRight):
+		if item := m.items[m.selected]; item.adjust != nil {
+			item.adjust(1)
+		}
+	case g.actionJustPressed(actionSelect):
+		item := m.items[m.selected]
+		if item.action == nil {
+			item.adjust(1)
+			return nil
+		}
+		return item.action()
+	}
+	return nil
+}
+
+// drawMenu draws the current menu over the game.
+func (g *game) drawMenu(screen *ebiten.Image) {
+	g.op.GeoM.Reset()
+	g.op.GeoM.Scale(float64(g.w)/32, float64(g.h)/32)
+	g.op.ColorM.Scale(1, 1, 1, 0.7)
+	screen.DrawImage(blackSquare, g.op)
+	g.op.ColorM.Reset()
+
+	m := g.menu
+
+	// Use smaller text when the menu would not fit on the screen.
+	scale, spacing := 4.0, 65.0
+	if float64(len(m.items))*spacing+300 > float64(g.h) {
+		scale, spacing = 3, 50
+	}
+
+	// Scroll menus which still do not fit on the screen.
+	visible := len(m.items)
+	if maxVisible := int((float64(g.h) - 300) / spacing); visible > maxVisible && maxVisible > 0 {
+		visible = maxVisible
+	}
+	first := m.selected - visible/2
+	if first > len(m.items)-visible {
+		first = len(m.items) - visible
+	}
+	if first < 0 {
+		first = 0
+	}
+
+	y := float64(g.h/2) - float64(visible)*spacing/2 - 150
+	g.drawCenteredText(screen, 0, y, 8, 1.0, m.title)
+	y += 200
+
+	for i := first; i < first+visible; i++ {
+		alpha := 0.4
+		if i == m.selected {
+			alpha = 1.0
+		}
+		g.drawCenteredText(screen, 0, y, scale, alpha, m.items[i].label())
+		y += spacing
+	}
+}
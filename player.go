@@ -1,38 +1,99 @@
 package main
 
 import (
-	"time"
+	"fmt"
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
 )
 
-var weaponUzi = &playerWeapon{
-	sprite:   imageAtlas[ImageUzi],
-	cooldown: 100 * time.Millisecond,
+// maxPlayers is the maximum number of local players.
+const maxPlayers = 4
+
+// playerColors are the color scales of each player, which are used to tell
+// players apart.
+var playerColors = [maxPlayers][3]float64{
+	{1, 1, 1},
+	{0.6, 0.8, 1},
+	{1, 0.65, 0.65},
+	{0.65, 1, 0.65},
 }
 
-type gamePlayer struct {
-	x, y float64
+// localPlayer is a player controlled by a keyboard and mouse or a gamepad.
+type localPlayer struct {
+	device ebiten.GamepadID
 
-	angle float64
+	initialButtonReleased bool
+}
 
-	weapon *playerWeapon
+// playerDevice returns the local player using a device, or nil.
+func (g *game) playerDevice(device ebiten.GamepadID) *localPlayer {
+	for _, lp := range g.players {
+		if lp != nil && lp.device == device {
+			return lp
+		}
+	}
+	return nil
+}
 
-	hasTorch bool
+// usingMouse returns whether a local player is using the keyboard and mouse.
+func (g *game) usingMouse() bool {
+	return g.playerDevice(keyboardMouse) != nil
+}
+
+// updateJoin adds a local player when the join action is pressed using a
+// device which does not control a player. Players join the world during the
+// next tick, when their input is first provided. Players which are in the
+// world but are not controlled by any device, such as after loading a game
+// with more players, are taken over before adding new players. It returns
+// whether a player joined.
+func (g *game) updateJoin() bool {
+	if g.replay != nil || !g.world.Alive() {
+		return false
+	}
 
-	score int
+	for _, device := range g.devices() {
+		if !g.deviceActionJustPressed(actionJoin, device) || g.playerDevice(device) != nil {
+			continue
+		}
 
-	soulsRescued int
+		lp := &localPlayer{
+			device: device,
+		}
 
-	health int
+		i := -1
+		for j, existing := range g.players {
+			if existing == nil {
+				i = j
+				break
+			}
+		}
+		if i == -1 {
+			if len(g.players) == maxPlayers {
+				return false
+			}
+			i = len(g.players)
+			g.players = append(g.players, lp)
+		} else {
+			g.players[i] = lp
+		}
 
-	garlicUntil    time.Time
-	holyWaterUntil time.Time
+		log.Printf("Player %d joined using device %d", i+1, device)
+		g.flashMessage(fmt.Sprintf("PLAYER %d JOINED", i+1))
+		g.updateCursor()
+		return true
+	}
+	return false
 }
 
-func NewPlayer() (*gamePlayer, error) {
-	p := &gamePlayer{
-		weapon:   weaponUzi,
-		hasTorch: true,
-		health:   3,
+// disconnectGamepad removes the local player using a gamepad. The player
+// remains in the world and may be taken over by another device.
+func (g *game) disconnectGamepad(id ebiten.GamepadID) {
+	for i, lp := range g.players {
+		if lp != nil && lp.device == id {
+			g.players[i] = nil
+			g.flashMessage(fmt.Sprintf("PLAYER %d DISCONNECTED", i+1))
+		}
 	}
-	return p, nil
+	g.updateCursor()
 }
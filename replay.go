@@ -0,0 +1,40 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	"code.rocketnine.space/tslocum/carotidartillery/world"
+)
+
+func loadReplay(filePath string) (*world.Replay, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return world.ReadReplay(f)
+}
+
+// saveReplay writes the replay of the current game, when one is being
+// recorded.
+func (g *game) saveReplay() {
+	if g.recording == nil {
+		return
+	}
+
+	f, err := os.Create(g.recordFile)
+	if err != nil {
+		log.Printf("failed to save replay: %s", err)
+		return
+	}
+	defer f.Close()
+
+	err = g.recording.Write(f)
+	if err != nil {
+		log.Printf("failed to save replay: %s", err)
+		return
+	}
+	log.Printf("Saved replay to %s (%d ticks)", g.recordFile, len(g.recording.Inputs))
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// quickSaveFile is written and read by the quicksave and quickload actions.
+	quickSaveFile = "quicksave.sav"
+	// autoSaveFile is written when the game exits and read by "Continue".
+	autoSaveFile = "autosave.sav"
+)
+
+// savePath returns the path of the named save file.
+func savePath(name string) (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, "carotidartillery", name), nil
+}
+
+func saveExists(name string) bool {
+	p, err := savePath(name)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(p)
+	return err == nil
+}
+
+// writeSave saves the current game to the named save file.
+func (g *game) writeSave(name string) error {
+	p, err := savePath(name)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(p), 0700)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return g.world.Save(f)
+}
+
+// quickSave saves the current game. Failing to save does not end the game.
+func (g *game) quickSave() {
+	if !g.gameOverTime.IsZero() {
+		return
+	}
+
+	err := g.writeSave(quickSaveFile)
+	if err != nil {
+		log.Printf("failed to save game: %s", err)
+		g.flashMessage("FAILED TO SAVE GAME")
+		return
+	}
+
+	g.hasSave = true
+	g.flashMessage("GAME SAVED")
+}
+
+// autoSave saves the current game so that it may be continued from the title
+// screen. The quicksave is left untouched.
+func (g *game) autoSave() {
+	if !g.gameOverTime.IsZero() {
+		return
+	}
+
+	err := g.writeSave(autoSaveFile)
+	if err != nil {
+		log.Printf("failed to autosave game: %s", err)
+		return
+	}
+	g.hasAutoSave = true
+}
+
+// quickLoad loads the game saved by quickSave.
+func (g *game) quickLoad() error {
+	if !g.hasSave {
+		return nil
+	}
+	return g.loadSave(quickSaveFile)
+}
+
+// continueGame loads the game saved by autoSave.
+func (g *game) continueGame() error {
+	if !g.hasAutoSave {
+		return nil
+	}
+	return g.loadSave(autoSaveFile)
+}
+
+// loadSave loads the named save file.
+func (g *game) loadSave(name string) error {
+	p, err := savePath(name)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(p)
+	if err != nil {
+		log.Printf("failed to load game: %s", err)
+		g.flashMessage("FAILED TO LOAD GAME")
+		return nil
+	}
+	defer f.Close()
+
+	err = g.world.Load(f)
+	if err != nil {
+		log.Printf("failed to load game: %s", err)
+		g.flashMessage("FAILED TO LOAD GAME")
+		return nil
+	}
+
+	g.resetGameState()
+
+	// Replays always start at the beginning of a game.
+	g.replay = nil
+	if g.recording != nil {
+		log.Println("Stopped recording replay: a saved game was loaded")
+		g.recording = nil
+	}
+
+	g.flashMessage("GAME LOADED")
+	return nil
+}
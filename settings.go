@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const settingsFile = "settings.json"
+
+// settings are the user preferences which persist between games.
+type settings struct {
+	Fullscreen bool
+
+	WindowWidth  int
+	WindowHeight int
+
+	MasterVolume float64
+	SFXVolume    float64
+	Mute         bool
+
+	GamepadDeadZone float64
+
+	DebugOverlay bool // Show the debug overlay without enabling debug mode
+}
+
+// windowSizes are the window sizes which may be selected in the options.
+var windowSizes = [][2]int{
+	{1280, 720},
+	{1600, 900},
+	{1920, 1080},
+	{2560, 1440},
+}
+
+func defaultSettings() *settings {
+	return &settings{
+		Fullscreen:      true,
+		WindowWidth:     1280,
+		WindowHeight:    720,
+		MasterVolume:    1,
+		SFXVolume:       1,
+		GamepadDeadZone: 0.1,
+	}
+}
+
+// loadSettings returns the saved settings. Default settings are returned when
+// no settings have been saved, or when they may not be read.
+func loadSettings() *settings {
+	s := defaultSettings()
+
+	data, err := readConfig(settingsFile)
+	if err != nil {
+		log.Printf("failed to read settings: %s", err)
+		return s
+	} else if data == nil {
+		return s
+	}
+
+	err = json.Unmarshal(data, s)
+	if err != nil {
+		log.Printf("failed to read settings: %s", err)
+		return defaultSettings()
+	}
+	return s
+}
+
+// saveSettings saves the current settings.
+func (g *game) saveSettings() {
+	data, err := json.MarshalIndent(g.settings, "", "\t")
+	if err != nil {
+		log.Printf("failed to save settings: %s", err)
+		return
+	}
+
+	err = writeConfig(settingsFile, data)
+	if err != nil {
+		log.Printf("failed to save settings: %s", err)
+	}
+}
+
+// applySettings applies the current settings. Any settings overridden by flags
+// are applied afterward.
+func (g *game) applySettings() {
+	ebiten.SetFullscreen(g.settings.Fullscreen)
+	ebiten.SetWindowSize(g.settings.WindowWidth, g.settings.WindowHeight)
+
+	g.muteAudio = g.settings.Mute
+}
+
+func (g *game) setFullscreen(fullscreen bool) {
+	ebiten.SetFullscreen(fullscreen)
+
+	g.settings.Fullscreen = fullscreen
+	g.saveSettings()
+}
+
+func (g *game) setMuteAudio(mute bool) {
+	g.muteAudio = mute
+	if g.music != nil {
+		if mute {
+			g.music.Pause()
+		} else {
+			g.music.Play()
+		}
+	}
+
+	g.settings.Mute = mute
+	g.saveSettings()
+}
+
+func (g *game) setDebugOverlay(overlay bool) {
+	g.settings.DebugOverlay = overlay
+	g.saveSettings()
+}
+
+// saveWindowSize saves the size of the window when the game is windowed.
+func (g *game) saveWindowSize() {
+	if ebiten.IsFullscreen() {
+		return
+	}
+
+	w, h := ebiten.WindowSize()
+	if w <= 0 || h <= 0 || (w == g.settings.WindowWidth && h == g.settings.WindowHeight) {
+		return
+	}
+	g.settings.WindowWidth, g.settings.WindowHeight = w, h
+	g.saveSettings()
+}
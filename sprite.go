@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+
+	"code.rocketnine.space/tslocum/carotidartillery/world"
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+var spriteAtlas map[world.SpriteID]*ebiten.Image
+
+// levelThemes are the tile sets which may be referenced by name in level
+// definitions.
+var levelThemes map[string]*EnvironmentSpriteSheet
+
+var creepSprites []*creepSpriteSet
+
+// batFormation are the animation frames of bats leading a swarm.
+var batFormation []*ebiten.Image
+
+// batSprites are the animation frames of each bat state.
+var batSprites map[int][]*ebiten.Image
+
+// loadBatSprites returns the animation frames of each bat state.
+func loadBatSprites() map[int][]*ebiten.Image {
+	return map[int][]*ebiten.Image{
+		world.BatRoosting:  batSS.Idle,
+		world.BatTakingOff: batSS.IdleToFly,
+		world.BatFlying:    creepSprites[world.TypeBat].frames,
+		world.BatBiting:    batSS.Bite,
+		world.BatHit:       batSS.HitAndDeath[:3],
+		world.BatDying:     batSS.HitAndDeath[3:],
+	}
+}
+
+// loadSpriteAtlas maps tile sprites to images of the loaded SpriteSheets,
+// using the provided tile set for floors, walls and doors.
+func loadSpriteAtlas(tiles *EnvironmentSpriteSheet) map[world.SpriteID]*ebiten.Image {
+	return map[world.SpriteID]*ebiten.Image{
+		world.SpriteFloorA:            tiles.FloorA,
+		world.SpriteFloorB:            tiles.FloorB,
+		world.SpriteFloorC:            tiles.FloorC,
+		world.SpriteWallTop:           tiles.WallTop,
+		world.SpriteWallBottom:        tiles.WallBottom,
+		world.SpriteWallBottomLeft:    tiles.WallBottomLeft,
+		world.SpriteWallBottomRight:   tiles.WallBottomRight,
+		world.SpriteWallLeft:          tiles.WallLeft,
+		world.SpriteWallRight:         tiles.WallRight,
+		world.SpriteWallTopLeft:       tiles.WallTopLeft,
+		world.SpriteWallTopRight:      tiles.WallTopRight,
+		world.SpriteWallPillar:        tiles.WallPillar,
+		world.SpriteTopDoorClosedL:    tiles.TopDoorClosedL,
+		world.SpriteTopDoorClosedR:    tiles.TopDoorClosedR,
+		world.SpriteTopDoorOpenTL:     tiles.TopDoorOpenTL,
+		world.SpriteTopDoorOpenTR:     tiles.TopDoorOpenTR,
+		world.SpriteTopDoorOpenBL:     tiles.TopDoorOpenBL,
+		world.SpriteTopDoorOpenBR:     tiles.TopDoorOpenBR,
+		world.SpriteBottomDoorClosedL: tiles.BottomDoorClosedL,
+		world.SpriteBottomDoorClosedR: tiles.BottomDoorClosedR,
+		world.SpriteBottomDoorOpenTL:  tiles.BottomDoorOpenTL,
+		world.SpriteBottomDoorOpenTR:  tiles.BottomDoorOpenTR,
+		world.SpriteBottomDoorOpenBL:  tiles.BottomDoorOpenBL,
+		world.SpriteBottomDoorOpenBR:  tiles.BottomDoorOpenBR,
+
+		world.SpriteGrass11:    ojasDungeonSS.Grass11,
+		world.SpriteGrass12:    ojasDungeonSS.Grass12,
+		world.SpriteGrass13:    ojasDungeonSS.Grass13,
+		world.SpriteGrass14:    ojasDungeonSS.Grass14,
+		world.SpriteGrass15:    ojasDungeonSS.Grass15,
+		world.SpriteGrass16:    ojasDungeonSS.Grass16,
+		world.SpriteGrass21:    ojasDungeonSS.Grass21,
+		world.SpriteGrass31:    ojasDungeonSS.Grass31,
+		world.SpriteGrass41:    ojasDungeonSS.Grass41,
+		world.SpriteGrass42:    ojasDungeonSS.Grass42,
+		world.SpriteGrass51:    ojasDungeonSS.Grass51,
+		world.SpriteGrass61:    ojasDungeonSS.Grass61,
+		world.SpriteGrass71:    ojasDungeonSS.Grass71,
+		world.SpriteGrass81:    ojasDungeonSS.Grass81,
+		world.SpriteGrass82:    ojasDungeonSS.Grass82,
+		world.SpriteGrass91:    ojasDungeonSS.Grass91,
+		world.SpriteOjasWall1:  ojasDungeonSS.Wall1,
+		world.SpriteOjasVent1:  ojasDungeonSS.Vent1,
+		world.SpriteOjasDoor11: ojasDungeonSS.Door11,
+		world.SpriteOjasDoor12: ojasDungeonSS.Door12,
+	}
+}
+
+// loadLevelThemes returns the tile sets which may be referenced by name in
+// level definitions, checking that the theme of each level exists.
+func loadLevelThemes() (map[string]*EnvironmentSpriteSheet, error) {
+	themes := map[string]*EnvironmentSpriteSheet{
+		"sandstone": sandstoneSS,
+	}
+	for i, def := range world.LevelDefinitions {
+		if themes[def.Theme] == nil {
+			return nil, fmt.Errorf("unknown theme %s of level %d", def.Theme, i+1)
+		}
+	}
+	return themes, nil
+}
+
+// creepSpriteSet holds the sprites of a type of creep.
+type creepSpriteSet struct {
+	frames  []*ebiten.Image
+	flipped []*ebiten.Image // Frames while facing left, if any
+	dead    *ebiten.Image   // Sprite of a destroyed static creep, if any
+}
+
+// namedSprites returns the sprites which may be referenced by name in creep
+// definitions.
+func namedSprites() map[string]*ebiten.Image {
+	return map[string]*ebiten.Image{
+		"vampire1": imageAtlas[ImageVampire1],
+		"vampire2": imageAtlas[ImageVampire2],
+		"vampire3": imageAtlas[ImageVampire3],
+		"bat1":     batSS.Frame1,
+		"bat2":     batSS.Frame2,
+		"bat3":     batSS.Frame3,
+		"bat4":     batSS.Frame4,
+		"bat5":     batSS.Frame5,
+		"bat6":     batSS.Frame6,
+		"bat7":     batSS.Frame7,
+		"ghost1":   imageAtlas[ImageGhost1],
+		"ghost1r":  imageAtlas[ImageGhost1R],
+		"ghost2":   imageAtlas[ImageGhost2],
+		"ghost2r":  imageAtlas[ImageGhost2R],
+		"soul1":    ojasDungeonSS.Soul1,
+		"torch1":   sandstoneSS.TorchTop1,
+		"torch2":   sandstoneSS.TorchTop2,
+		"torch3":   sandstoneSS.TorchTop3,
+		"torch4":   sandstoneSS.TorchTop4,
+		"torch5":   sandstoneSS.TorchTop5,
+		"torch6":   sandstoneSS.TorchTop6,
+		"torch7":   sandstoneSS.TorchTop7,
+		"torch8":   sandstoneSS.TorchTop8,
+		"torch9":   sandstoneSS.TorchTop9,
+	}
+}
+
+// loadCreepSprites returns the sprites of each type of creep, as named in the
+// creep definitions.
+func loadCreepSprites() ([]*creepSpriteSet, error) {
+	named := namedSprites()
+
+	lookup := func(def *world.CreepDefinition, names []string) ([]*ebiten.Image, error) {
+		sprites := make([]*ebiten.Image, len(names))
+		for i, name := range names {
+			sprite, ok := named[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown sprite %s of creep %s", name, def.Name)
+			}
+			sprites[i] = sprite
+		}
+		return sprites, nil
+	}
+
+	sets := make([]*creepSpriteSet, len(world.CreepDefinitions))
+	for creepType, def := range world.CreepDefinitions {
+		set := &creepSpriteSet{}
+
+		var err error
+		set.frames, err = lookup(def, def.Sprites)
+		if err != nil {
+			return nil, err
+		}
+		set.flipped, err = lookup(def, def.FlippedSprites)
+		if err != nil {
+			return nil, err
+		}
+		if def.DeadSprite != "" {
+			dead, err := lookup(def, []string{def.DeadSprite})
+			if err != nil {
+				return nil, err
+			}
+			set.dead = dead[0]
+		}
+
+		sets[creepType] = set
+	}
+	return sets, nil
+}
+
+// creepSprite returns the current sprite of a creep.
+func (g *game) creepSprite(c *world.Creep) *ebiten.Image {
+	if sprite, ok := g.propSprites[c]; ok {
+		return sprite
+	}
+
+	sprites := creepSprites[c.CreepType]
+	switch {
+	case c.Health <= 0 && sprites.dead != nil:
+		return sprites.dead
+	case c.CreepType == world.TypeBat && c.Followers > 0 && c.State == world.BatFlying:
+		// Swarm leaders are drawn as a formation of bats.
+		return batFormation[c.Frame%len(batFormation)]
+	case c.CreepType == world.TypeBat:
+		return batSprites[c.State][c.Frame]
+	case c.Flipped && len(sprites.flipped) > 0:
+		return sprites.flipped[c.Frame%len(sprites.flipped)]
+	}
+	return sprites.frames[c.Frame]
+}
+
+// itemSprite returns the sprite of an item.
+func itemSprite(item *world.Item) *ebiten.Image {
+	if item.ItemType == world.ItemTypeHolyWater {
+		return imageAtlas[ImageHolyWater]
+	}
+	return imageAtlas[ImageGarlic]
+}
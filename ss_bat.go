@@ -18,6 +18,15 @@ type BatSpriteSheet struct {
 	Frame5 *ebiten.Image
 	Frame6 *ebiten.Image
 	Frame7 *ebiten.Image
+
+	Formation1 *ebiten.Image
+	Formation2 *ebiten.Image
+	Formation3 *ebiten.Image
+
+	Idle        []*ebiten.Image
+	IdleToFly   []*ebiten.Image
+	Bite        []*ebiten.Image
+	HitAndDeath []*ebiten.Image
 }
 
 // LoadBatSpriteSheet loads the embedded BatSpriteSheet.
@@ -50,5 +59,53 @@ func LoadBatSpriteSheet() (*BatSpriteSheet, error) {
 	s.Frame6 = spriteAt(5, 0)
 	s.Frame7 = spriteAt(6, 0)
 
+	formation, err := loadBatStrip("formation.png", 0)
+	if err != nil {
+		return nil, err
+	}
+	s.Formation1, s.Formation2, s.Formation3 = formation[0], formation[1], formation[2]
+
+	for _, strip := range []struct {
+		file   string
+		top    int // Top of the area of each frame containing the bat
+		frames *[]*ebiten.Image
+	}{
+		{"idle.png", 0, &s.Idle},
+		{"idle-to-fly.png", 0, &s.IdleToFly},
+		{"bite.png", 38, &s.Bite},
+		{"hit-and-death.png", 40, &s.HitAndDeath},
+	} {
+		*strip.frames, err = loadBatStrip(strip.file, strip.top)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return s, nil
 }
+
+// loadBatStrip loads an animation strip of the bat. Frames are wider than a
+// tile and include a shadow far below the bat, so each frame is cropped to a
+// tile starting at top.
+func loadBatStrip(file string, top int) ([]*ebiten.Image, error) {
+	const tileSize = 32
+	const frameWidth = 44
+
+	f, err := assetsFS.Open("assets/creeps/bat/" + file)
+	if err != nil {
+		return nil, err
+	}
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, err
+	}
+
+	sheet := ebiten.NewImageFromImage(img)
+
+	frames := make([]*ebiten.Image, sheet.Bounds().Dx()/frameWidth)
+	for i := range frames {
+		left := i*frameWidth + (frameWidth-tileSize)/2
+		frames[i] = sheet.SubImage(image.Rect(left, top, left+tileSize, top+tileSize)).(*ebiten.Image)
+	}
+	return frames, nil
+}
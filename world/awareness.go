@@ -0,0 +1,186 @@
+package world
+
+import (
+	"math"
+)
+
+// Awareness states of creeps.
+const (
+	AwarenessUnaware    = iota // Wandering
+	AwarenessSuspicious        // Investigating the last known position of a player
+	AwarenessHunting           // Chasing a player
+)
+
+const (
+	// playerTorchLight is the brightness added by the torch carried by a
+	// player to the light at their position.
+	playerTorchLight = 0.6
+
+	// huntMemory is the number of ticks a hunting creep chases the last
+	// known position of a player after losing sight of them.
+	huntMemory = 144 * 3
+
+	// suspicionMemory is the number of ticks a suspicious creep remains
+	// suspicious after losing sight of a player.
+	suspicionMemory = 144 * 6
+)
+
+// Raycast follows a line between two positions through the tiles of the
+// Level. It returns the first tile along the line which is not floor, and
+// whether such a tile was found. Positions are rounded to tiles in the same
+// way as IsFloor.
+func (l *Level) Raycast(x1, y1, x2, y2 float64) (int, int, bool) {
+	// Tiles are centered on whole positions.
+	x1, y1, x2, y2 = x1+.5, y1+.5, x2+.5, y2+.5
+
+	tx, ty := int(math.Floor(x1)), int(math.Floor(y1))
+	endX, endY := int(math.Floor(x2)), int(math.Floor(y2))
+	dx, dy := x2-x1, y2-y1
+
+	// Distance along the line, from 0 to 1, between tile boundaries and to
+	// the next boundary on each axis.
+	stepX, deltaX, maxX := 0, math.Inf(1), math.Inf(1)
+	if dx > 0 {
+		stepX, deltaX, maxX = 1, 1/dx, (float64(tx+1)-x1)/dx
+	} else if dx < 0 {
+		stepX, deltaX, maxX = -1, -1/dx, (x1-float64(tx))/-dx
+	}
+	stepY, deltaY, maxY := 0, math.Inf(1), math.Inf(1)
+	if dy > 0 {
+		stepY, deltaY, maxY = 1, 1/dy, (float64(ty+1)-y1)/dy
+	} else if dy < 0 {
+		stepY, deltaY, maxY = -1, -1/dy, (y1-float64(ty))/-dy
+	}
+
+	for {
+		t := l.Tile(tx, ty)
+		if t == nil || !t.Floor {
+			return tx, ty, true
+		}
+		if (tx == endX && ty == endY) || (maxX > 1 && maxY > 1) {
+			return 0, 0, false
+		}
+
+		if maxX < maxY {
+			maxX += deltaX
+			tx += stepX
+		} else {
+			maxY += deltaY
+			ty += stepY
+		}
+	}
+}
+
+// LineOfSight returns whether there is an unobstructed line between two
+// positions.
+func (l *Level) LineOfSight(x1, y1, x2, y2 float64) bool {
+	_, _, blocked := l.Raycast(x1, y1, x2, y2)
+	return !blocked
+}
+
+// playerLight returns the brightness at the position of a player, between 0
+// and 1, including the light of the torch they carry.
+func (l *Level) playerLight(p *Player) float64 {
+	var v float64
+	if t := l.Tile(int(math.Floor(p.X+.5)), int(math.Floor(p.Y+.5))); t != nil {
+		v = t.ColorScale
+	}
+	if p.HasTorch {
+		v += playerTorchLight
+	}
+	if v > 1 {
+		v = 1
+	}
+	return v
+}
+
+// sees returns whether the creep is able to see a player. Players are seen
+// from further away when they are brightly lit.
+func (c *Creep) sees(p *Player) bool {
+	sight := c.Definition().SightRadius * c.level.playerLight(p)
+	dx, dy := DeltaXY(c.X, c.Y, p.X, p.Y)
+	if dx > sight || dy > sight {
+		return false
+	}
+	return c.level.LineOfSight(c.X, c.Y, p.X, p.Y)
+}
+
+// alert makes an unaware creep suspicious of a position, such as where a
+// gunshot was heard.
+func (c *Creep) alert(x, y float64) {
+	if c.Awareness == AwarenessHunting {
+		return
+	}
+	c.Awareness = AwarenessSuspicious
+	c.lastKnownX, c.lastKnownY = x, y
+	c.awareTicks = 0
+}
+
+// updateAwareness updates the awareness of the creep of its player and moves
+// toward them when the creep is hunting or suspicious. It returns whether the
+// movement of the creep was chosen.
+func (c *Creep) updateAwareness(repelled bool) bool {
+	def := c.Definition()
+	p := c.player
+
+	if c.sees(p) {
+		dx, dy := DeltaXY(c.X, c.Y, p.X, p.Y)
+		if dx < def.SeekRadius && dy < def.SeekRadius {
+			c.Awareness = AwarenessHunting
+		} else if c.Awareness == AwarenessUnaware {
+			c.Awareness = AwarenessSuspicious
+		}
+		c.lastKnownX, c.lastKnownY = p.X, p.Y
+		c.awareTicks = 0
+
+		if c.Awareness == AwarenessHunting && !repelled {
+			c.queueNextAction()
+			if def.Ranged != nil {
+				c.keepDistance()
+			} else {
+				c.seekPlayer()
+			}
+			return true
+		}
+	} else {
+		c.awareTicks++
+	}
+
+	switch c.Awareness {
+	case AwarenessHunting:
+		if c.awareTicks > huntMemory || c.reachedLastKnown() {
+			c.Awareness = AwarenessSuspicious
+			c.awareTicks = 0
+			c.seeking = false
+		}
+	case AwarenessSuspicious:
+		if c.awareTicks > suspicionMemory {
+			c.Awareness = AwarenessUnaware
+			return false
+		}
+	default:
+		return false
+	}
+
+	if repelled || c.reachedLastKnown() {
+		return false
+	}
+
+	// Move toward the last known position of the player. Suspicious creeps
+	// move cautiously.
+	speed := c.moveSpeed() / 9
+	if c.Awareness == AwarenessSuspicious {
+		speed /= 2
+	}
+	a := Angle(c.lastKnownX, c.lastKnownY, c.X, c.Y)
+	c.moveX, c.moveY = math.Cos(a)*speed, math.Sin(a)*speed
+	c.seeking = false
+	return true
+}
+
+// reachedLastKnown returns whether the creep has reached the last known
+// position of its player.
+func (c *Creep) reachedLastKnown() bool {
+	dx, dy := DeltaXY(c.X, c.Y, c.lastKnownX, c.lastKnownY)
+	return dx < 0.5 && dy < 0.5
+}
@@ -0,0 +1,91 @@
+package world
+
+// Bat states, which determine the animation played by bats.
+const (
+	BatRoosting = iota
+	BatTakingOff
+	BatFlying
+	BatBiting
+	BatHit
+	BatDying
+	BatDead
+)
+
+// batStateFrames are the number of animation frames of each bat state.
+var batStateFrames = map[int]int{
+	BatRoosting:  7,
+	BatTakingOff: 6,
+	BatFlying:    7,
+	BatBiting:    8,
+	BatHit:       3,
+	BatDying:     4,
+}
+
+const (
+	// batWakeDistance is the distance at which a player wakes a roosting bat.
+	batWakeDistance = 4
+
+	// batBiteDistance is the distance at which a flying bat prepares to bite.
+	batBiteDistance = 2
+)
+
+// setState changes the state of a bat and starts its animation.
+func (c *Creep) setState(state int) {
+	c.State = state
+	c.Frame = 0
+	c.Frames = batStateFrames[state]
+	c.LastFrame = c.level.clock.Now()
+}
+
+// Dying returns whether the creep was killed and is playing its death
+// animation.
+func (c *Creep) Dying() bool {
+	return c.CreepType == TypeBat && c.State == BatDying
+}
+
+// updateBat updates the state of a living bat. It returns whether the bat
+// is able to move.
+func (c *Creep) updateBat() bool {
+	switch c.State {
+	case BatRoosting:
+		dx, dy := DeltaXY(c.X, c.Y, c.player.X, c.player.Y)
+		if (dx < batWakeDistance && dy < batWakeDistance) || c.tick >= c.nextAction {
+			c.setState(BatTakingOff)
+		}
+		return false
+	case BatTakingOff:
+		return false
+	case BatFlying, BatBiting:
+		dx, dy := DeltaXY(c.X, c.Y, c.player.X, c.player.Y)
+		near := dx < batBiteDistance && dy < batBiteDistance
+		if near && c.State == BatFlying {
+			c.setState(BatBiting)
+		} else if !near && c.State == BatBiting {
+			c.setState(BatFlying)
+		}
+	}
+	return true
+}
+
+// batAnimationEnded is called after a bat plays the last frame of its
+// animation.
+func (c *Creep) batAnimationEnded() {
+	switch c.State {
+	case BatTakingOff:
+		c.setState(BatFlying)
+		c.tick = c.nextAction // Choose a direction immediately.
+	case BatHit:
+		c.setState(BatFlying)
+	case BatDying:
+		c.setState(BatDead)
+	}
+}
+
+// hurtBat plays the hit or death animation of a bat which was hurt.
+func (c *Creep) hurtBat() {
+	if c.Health > 0 {
+		c.setState(BatHit)
+		return
+	}
+	c.setState(BatDying)
+}
@@ -0,0 +1,205 @@
+package world
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// bossBiteCooldown is the time between bites of a boss, which is not
+	// consumed when it bites a player.
+	bossBiteCooldown = 1500 * time.Millisecond
+
+	// bossLeash is the distance a boss follows players away from the exit it
+	// guards.
+	bossLeash = 14
+
+	bossWindUpTicks = 72
+	bossDashTicks   = 72
+
+	// bossSwarmSize is the number of bats summoned by a boss at once.
+	bossSwarmSize = 5
+)
+
+// Boss attacks.
+const (
+	bossSummon = iota
+	bossDash
+	bossTeleport
+)
+
+// bossState holds the state of a boss creep.
+type bossState struct {
+	GuardX, GuardY float64 // Position guarded by the boss
+
+	NextAttack int // Ticks until the next attack
+	WindUp     int // Ticks until a dash starts
+	Dash       int // Ticks remaining in a dash
+
+	DashX, DashY float64
+
+	LastBite time.Time
+}
+
+// BossPhase returns the attack phase of a boss, which advances from 1 to 3 as
+// it loses health.
+func (c *Creep) BossPhase() int {
+	maxHealth := c.Definition().Health
+	phase := 1 + (maxHealth-c.Health)*3/maxHealth
+	if phase > 3 {
+		phase = 3
+	}
+	return phase
+}
+
+// bossAttacks returns the attacks available to a boss in each phase and the
+// number of ticks between attacks.
+func bossAttacks(phase int) ([]int, int) {
+	switch phase {
+	case 1:
+		return []int{bossSummon}, 144 * 6
+	case 2:
+		return []int{bossSummon, bossDash}, 144 * 4
+	default:
+		return []int{bossSummon, bossDash, bossTeleport}, 144 * 3
+	}
+}
+
+// AddBoss adds a boss guarding the exit of the Level.
+func (l *Level) AddBoss(creepType int) *Creep {
+	// Stand on the nearest floor below the exit.
+	x, y := float64(l.ExitX)+0.5, float64(l.ExitY)+2
+FINDFLOOR:
+	for r := 0; r < l.H; r++ {
+		for dy := 0; dy <= r; dy++ {
+			for _, dx := range []int{0, -r, r} {
+				if l.IsFloor(x+float64(dx), y+float64(dy)) {
+					x, y = x+float64(dx), y+float64(dy)
+					break FINDFLOOR
+				}
+			}
+		}
+	}
+	return l.addBoss(creepType, x, y)
+}
+
+// addBoss adds a boss guarding the provided position.
+func (l *Level) addBoss(creepType int, x, y float64) *Creep {
+	c := newCreepAt(creepType, l, x, y)
+
+	c.boss = &bossState{
+		GuardX:     x,
+		GuardY:     y,
+		NextAttack: 144 * 3,
+	}
+	l.addCreep(c)
+	l.Boss = c
+	return c
+}
+
+// moveBoss moves a boss toward its player, returning to the position it guards
+// when its player is too far away. Bosses stand still while winding up a dash.
+func (c *Creep) moveBoss() {
+	b := c.boss
+	switch {
+	case b.Dash > 0:
+		b.Dash--
+		c.moveX, c.moveY = b.DashX, b.DashY
+		return
+	case b.WindUp > 0:
+		b.WindUp--
+		c.moveX, c.moveY = 0, 0
+		if b.WindUp == 0 {
+			// Dash toward the player.
+			a := Angle(c.player.X, c.player.Y, c.X, c.Y)
+			speed := c.moveSpeed() / 3
+			b.DashX, b.DashY = math.Cos(a)*speed, math.Sin(a)*speed
+			b.Dash = bossDashTicks
+		}
+		return
+	}
+
+	dx, dy := DeltaXY(c.player.X, c.player.Y, b.GuardX, b.GuardY)
+	if dx > bossLeash || dy > bossLeash {
+		a := Angle(b.GuardX, b.GuardY, c.X, c.Y)
+		speed := c.moveSpeed() / 9
+		c.moveX, c.moveY = math.Cos(a)*speed, math.Sin(a)*speed
+		c.seeking = false
+		return
+	}
+
+	c.seeking = true
+	c.steer()
+}
+
+// updateBoss performs the attacks of the boss of the current Level.
+func (w *World) updateBoss() {
+	c := w.Level.Boss
+	if c == nil || c.Health <= 0 || c.player == nil {
+		return
+	}
+	b := c.boss
+
+	if b.WindUp > 0 || b.Dash > 0 {
+		return
+	}
+
+	b.NextAttack--
+	if b.NextAttack > 0 {
+		return
+	}
+
+	attacks, interval := bossAttacks(c.BossPhase())
+	b.NextAttack = interval
+
+	switch attacks[w.rng.Intn(len(attacks))] {
+	case bossSummon:
+		for _, bat := range w.Level.AddSwarm(bossSwarmSize) {
+			w.Level.moveCreep(bat, c.X, c.Y)
+		}
+		w.addEvent(Event{EventType: EventMessage, Message: "VAMPIRE LORD SUMMONS BATS"})
+	case bossDash:
+		b.WindUp = bossWindUpTicks
+		w.addEvent(Event{EventType: EventMessage, Message: "VAMPIRE LORD DASHES"})
+	case bossTeleport:
+		x, y, ok := w.torchFloor()
+		if !ok {
+			return
+		}
+		w.Level.moveCreep(c, x, y)
+		w.addEvent(Event{EventType: EventMessage, Message: "VAMPIRE LORD TELEPORTS"})
+	}
+}
+
+// torchFloor returns a random floor position next to a lit torch.
+func (w *World) torchFloor() (float64, float64, bool) {
+	var lit []*Creep
+	for _, torch := range w.Level.Torches {
+		if torch.Health > 0 {
+			lit = append(lit, torch)
+		}
+	}
+	if len(lit) == 0 {
+		return 0, 0, false
+	}
+
+	torch := lit[w.rng.Intn(len(lit))]
+	for _, offset := range [][2]float64{{0, 1}, {-1, 0}, {1, 0}, {0, -1}} {
+		x, y := torch.X+offset[0], torch.Y+offset[1]
+		if w.Level.IsFloor(x, y) {
+			return x, y, true
+		}
+	}
+	return 0, 0, false
+}
+
+// bossBite handles a boss reaching a player. Bosses are not consumed by
+// biting, and instead wait before biting again. It returns whether the player
+// was bitten.
+func (w *World) bossBite(c *Creep) bool {
+	if w.Clock.Since(c.boss.LastBite) < bossBiteCooldown {
+		return false
+	}
+	c.boss.LastBite = w.Clock.Now()
+	return true
+}
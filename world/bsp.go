@@ -0,0 +1,96 @@
+package world
+
+import (
+	"fmt"
+	"image"
+	"math/rand"
+)
+
+// BSPGenerator generates levels of rectangular rooms connected by straight
+// corridors. The level is split in two repeatedly (binary space
+// partitioning), a room is placed in each resulting area and the rooms of
+// each pair of areas are connected. The entrance is placed in the first room
+// and the exit in the last room.
+type BSPGenerator struct {
+	MinRoomSize   int // Minimum width and height of rooms
+	CorridorWidth int
+}
+
+// Generate returns a new layout of the provided size.
+func (g *BSPGenerator) Generate(w, h int, rng *rand.Rand) (*LevelLayout, error) {
+	layout := newLevelLayout(w, h)
+
+	var rooms []image.Rectangle
+	g.split(image.Rect(1, 1, w-1, h-1), layout, rng, &rooms)
+	if len(rooms) < 2 {
+		return nil, fmt.Errorf("level size %dx%d is too small", w, h)
+	}
+
+	// Doors are placed in the wall below the first room and above the last.
+	first, last := rooms[0], rooms[len(rooms)-1]
+	for x := first.Min.X; x < first.Max.X; x++ {
+		layout.Entrances = append(layout.Entrances, [2]int{x, first.Max.Y})
+	}
+	for x := last.Min.X; x < last.Max.X; x++ {
+		layout.Exits = append(layout.Exits, [2]int{x, last.Min.Y - 1})
+	}
+	return layout, nil
+}
+
+// split splits an area of the level in two until it is too small to contain
+// two rooms, placing a room in each area and connecting them. It returns one
+// of the rooms within the area.
+func (g *BSPGenerator) split(area image.Rectangle, layout *LevelLayout, rng *rand.Rand, rooms *[]image.Rectangle) image.Rectangle {
+	// Rooms are surrounded by walls.
+	minArea := g.MinRoomSize + 2
+
+	vertical := area.Dx() > area.Dy()
+	size := area.Dy()
+	if vertical {
+		size = area.Dx()
+	}
+	if size < minArea*2 {
+		// Place a room.
+		rw := g.MinRoomSize + rng.Intn(area.Dx()-minArea+1)
+		rh := g.MinRoomSize + rng.Intn(area.Dy()-minArea+1)
+		x := area.Min.X + 1 + rng.Intn(area.Dx()-rw-1)
+		y := area.Min.Y + 1 + rng.Intn(area.Dy()-rh-1)
+		room := image.Rect(x, y, x+rw, y+rh)
+		g.carve(layout, room)
+		*rooms = append(*rooms, room)
+		return room
+	}
+
+	at := minArea + rng.Intn(size-minArea*2+1)
+	a, b := area, area
+	if vertical {
+		a.Max.X, b.Min.X = area.Min.X+at, area.Min.X+at
+	} else {
+		a.Max.Y, b.Min.Y = area.Min.Y+at, area.Min.Y+at
+	}
+	roomA := g.split(a, layout, rng, rooms)
+	roomB := g.split(b, layout, rng, rooms)
+
+	// Connect the centers of the rooms, first horizontally and then
+	// vertically.
+	ax, ay := (roomA.Min.X+roomA.Max.X)/2, (roomA.Min.Y+roomA.Max.Y)/2
+	bx, by := (roomB.Min.X+roomB.Max.X)/2, (roomB.Min.Y+roomB.Max.Y)/2
+	g.carve(layout, image.Rect(ax, ay, bx, ay+g.CorridorWidth))
+	g.carve(layout, image.Rect(bx, ay, bx+g.CorridorWidth, ay+g.CorridorWidth))
+	g.carve(layout, image.Rect(bx, ay, bx+g.CorridorWidth, by))
+
+	if rng.Intn(2) == 0 {
+		return roomA
+	}
+	return roomB
+}
+
+// carve turns an area of the level into floor.
+func (g *BSPGenerator) carve(layout *LevelLayout, r image.Rectangle) {
+	r = r.Intersect(image.Rect(1, 1, layout.W-1, layout.H-1))
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			layout.Floor[y][x] = true
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package world
+
+import (
+	"errors"
+	"math/rand"
+)
+
+// CaveGenerator generates levels of natural caves using cellular automata.
+// Cells start as rock or floor at random, and are then repeatedly smoothed
+// based on their neighbors. Only the largest cave is kept.
+type CaveGenerator struct {
+	FillChance float64 // Chance of each cell starting as rock
+	Steps      int     // Number of smoothing steps
+	Scale      int     // Width and height of each cell in tiles
+}
+
+// Generate returns a new layout of the provided size.
+func (g *CaveGenerator) Generate(w, h int, rng *rand.Rand) (*LevelLayout, error) {
+	cw, ch := w/g.Scale, h/g.Scale
+
+	border := func(x, y int) bool {
+		return x <= 0 || y <= 0 || x >= cw-1 || y >= ch-1
+	}
+
+	rock := make([][]bool, ch)
+	for y := range rock {
+		rock[y] = make([]bool, cw)
+		for x := range rock[y] {
+			rock[y][x] = border(x, y) || rng.Float64() < g.FillChance
+		}
+	}
+
+	// rockNeighbors returns the number of rock cells surrounding a cell.
+	rockNeighbors := func(x, y int) int {
+		var n int
+		for ny := y - 1; ny <= y+1; ny++ {
+			for nx := x - 1; nx <= x+1; nx++ {
+				if (nx != x || ny != y) && (border(nx, ny) || rock[ny][nx]) {
+					n++
+				}
+			}
+		}
+		return n
+	}
+
+	for i := 0; i < g.Steps; i++ {
+		next := make([][]bool, ch)
+		for y := range next {
+			next[y] = make([]bool, cw)
+			for x := range next[y] {
+				if border(x, y) {
+					next[y][x] = true
+					continue
+				}
+				n := rockNeighbors(x, y)
+				next[y][x] = n >= 5 || (rock[y][x] && n >= 4)
+			}
+		}
+		rock = next
+	}
+
+	// Find the largest cave.
+	region := make([][]int, ch)
+	for y := range region {
+		region[y] = make([]int, cw)
+	}
+	var largest, largestSize int
+	regions := 0
+	for y := 0; y < ch; y++ {
+		for x := 0; x < cw; x++ {
+			if rock[y][x] || region[y][x] != 0 {
+				continue
+			}
+			regions++
+			size := 0
+			stack := [][2]int{{x, y}}
+			region[y][x] = regions
+			for len(stack) > 0 {
+				cell := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				size++
+				for _, n := range [][2]int{{cell[0] - 1, cell[1]}, {cell[0] + 1, cell[1]}, {cell[0], cell[1] - 1}, {cell[0], cell[1] + 1}} {
+					if !rock[n[1]][n[0]] && region[n[1]][n[0]] == 0 {
+						region[n[1]][n[0]] = regions
+						stack = append(stack, n)
+					}
+				}
+			}
+			if size > largestSize {
+				largest, largestSize = regions, size
+			}
+		}
+	}
+	if largestSize < cw*ch/4 {
+		return nil, errors.New("cave is too small")
+	}
+
+	layout := newLevelLayout(w, h)
+	for y := 0; y < ch*g.Scale; y++ {
+		for x := 0; x < cw*g.Scale; x++ {
+			layout.Floor[y][x] = region[y/g.Scale][x/g.Scale] == largest
+		}
+	}
+	return layout, nil
+}
@@ -0,0 +1,87 @@
+package world
+
+import (
+	"fmt"
+)
+
+const (
+	CheatNone = iota
+	CheatGodMode
+	CheatNoclipMode
+	CheatSpawnVampires
+	CheatSpawnBats
+	CheatSpawnGhosts
+	CheatIncreaseHealth
+	CheatSkipSouls
+	CheatNextLevel
+	CheatWin
+)
+
+func (w *World) cheatMessage(message string) {
+	w.addEvent(Event{EventType: EventCheat, Message: message})
+}
+
+func (w *World) applyCheat(cheat int) error {
+	spawnAmount := 13
+	switch cheat {
+	case CheatGodMode:
+		w.GodMode = !w.GodMode
+		if w.GodMode {
+			w.cheatMessage("GOD MODE ACTIVATED")
+		} else {
+			w.cheatMessage("GOD MODE DEACTIVATED")
+		}
+	case CheatNoclipMode:
+		w.NoclipMode = !w.NoclipMode
+		if w.NoclipMode {
+			w.cheatMessage("NOCLIP MODE ACTIVATED")
+		} else {
+			w.cheatMessage("NOCLIP MODE DEACTIVATED")
+		}
+	case CheatSpawnVampires:
+		for i := 0; i < spawnAmount; i++ {
+			w.Level.AddCreep(TypeVampire)
+		}
+		w.cheatMessage(fmt.Sprintf("SPAWNED %d VAMPIRES", spawnAmount))
+	case CheatSpawnBats:
+		for i := 0; i < spawnAmount; i++ {
+			w.Level.AddCreep(TypeBat)
+		}
+		w.cheatMessage(fmt.Sprintf("SPAWNED %d BATS", spawnAmount))
+	case CheatSpawnGhosts:
+		for i := 0; i < spawnAmount; i++ {
+			w.Level.AddCreep(TypeGhost)
+		}
+		w.cheatMessage(fmt.Sprintf("SPAWNED %d GHOSTS", spawnAmount))
+	case CheatIncreaseHealth:
+		for _, p := range w.Players {
+			if p.Health > 0 {
+				p.Health++
+			}
+		}
+		w.cheatMessage("INCREASED HEALTH")
+	case CheatSkipSouls:
+		if w.SoulsRescued < w.Level.RequiredSouls {
+			w.SoulsRescued = w.Level.RequiredSouls
+			w.CheckLevelComplete()
+			w.cheatMessage("SKIPPED SOUL COLLECTION")
+		} else {
+			for _, p := range w.Players {
+				p.X, p.Y = float64(w.Level.ExitX)+0.5, float64(w.Level.ExitY+2)
+			}
+			w.cheatMessage("WARPED TO EXIT")
+		}
+	case CheatNextLevel:
+		err := w.NextLevel()
+		if err != nil {
+			return err
+		}
+		if w.LevelDefinition() != nil {
+			w.cheatMessage(fmt.Sprintf("WARPED TO LEVEL %d", w.LevelNum))
+		}
+	case CheatWin:
+		w.addEvent(Event{EventType: EventWin})
+		w.cheatMessage("WARPED TO WIN SCREEN")
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package world
+
+import (
+	"time"
+)
+
+// TPS is the number of simulation ticks per second.
+const TPS = 144
+
+// TickDuration is the amount of simulation time which passes each tick.
+const TickDuration = time.Second / TPS
+
+// clockEpoch is the simulation time at tick zero. It is not the zero time, so
+// unset timestamps may still be detected using time.Time.IsZero.
+var clockEpoch = time.Unix(0, 0)
+
+// Clock is the simulation clock. Time only passes when the clock is advanced,
+// so any timers based on it are unaffected by pauses, slow motion or
+// fast-forwarding.
+type Clock struct {
+	tick int
+
+	speed float64 // Simulation speed multiplier
+	steps float64 // Partial ticks carried between updates
+}
+
+// NewClock returns a new Clock.
+func NewClock() *Clock {
+	return &Clock{
+		speed: 1,
+	}
+}
+
+// Tick returns the number of ticks which have elapsed.
+func (c *Clock) Tick() int {
+	return c.tick
+}
+
+// Now returns the current simulation time.
+func (c *Clock) Now() time.Time {
+	return clockEpoch.Add(time.Duration(c.tick) * TickDuration)
+}
+
+// Since returns the simulation time elapsed since t.
+func (c *Clock) Since(t time.Time) time.Duration {
+	return c.Now().Sub(t)
+}
+
+// Until returns the simulation time remaining until t.
+func (c *Clock) Until(t time.Time) time.Duration {
+	return t.Sub(c.Now())
+}
+
+// Advance advances the clock by one tick.
+func (c *Clock) Advance() {
+	c.tick++
+}
+
+// Steps returns the number of ticks to simulate during the current update,
+// taking the simulation speed into account.
+func (c *Clock) Steps() int {
+	c.steps += c.speed
+	steps := int(c.steps)
+	c.steps -= float64(steps)
+	return steps
+}
+
+// Speed returns the simulation speed multiplier.
+func (c *Clock) Speed() float64 {
+	return c.speed
+}
+
+// SetSpeed sets the simulation speed multiplier.
+func (c *Clock) SetSpeed(speed float64) {
+	c.speed = speed
+	c.steps = 0
+}
+
+// Reset rewinds the clock to tick zero.
+func (c *Clock) Reset() {
+	c.tick = 0
+	c.steps = 0
+}
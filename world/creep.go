@@ -0,0 +1,359 @@
+package world
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// Types of the creeps which are referred to directly. Each type is resolved
+// by name when the creep definitions are loaded.
+var (
+	TypeVampire     = -1
+	TypeBat         = -1
+	TypeGhost       = -1
+	TypeSoul        = -1
+	TypeTorch       = -1
+	TypeVampireLord = -1
+	TypeBloodMage   = -1
+)
+
+// Creep represents a creature or object within a Level.
+type Creep struct {
+	X, Y float64
+
+	Frame     int
+	Frames    int
+	LastFrame time.Time
+
+	CreepType int
+
+	moveX, moveY float64
+
+	tick       int
+	nextAction int
+
+	seeking bool // Whether the creep is following a path to the player
+
+	leader    *Creep // Swarm leader followed by the creep, or nil
+	Followers int    // Number of creeps following the creep in a swarm
+
+	boss *bossState // State of a boss, or nil
+
+	Awareness              int
+	lastKnownX, lastKnownY float64 // Last known position of the player
+	awareTicks             int     // Ticks since the player was last seen
+
+	LastHit        time.Time // Time the creep was last hit by a projectile
+	stagger        int       // Ticks remaining until the creep recovers from being hit
+	knockX, knockY float64   // Initial knockback movement
+
+	reload int // Ticks until a ranged creep is able to fire
+
+	SoulsEaten int    // Souls eaten by the creep, each making it stronger
+	prey       *Creep // Soul sought by the creep, or nil
+
+	level  *Level
+	player *Player // Nearest living player, updated every tick
+
+	id   int // Order in which the creep was added to the level
+	cell int // Cell of the spatial hash containing the creep, or -1
+
+	rng *rand.Rand
+
+	Health int
+
+	Angle   float64
+	Flipped bool // Whether the creep is facing left
+
+	State int // State of a bat
+
+	Simulation int // Simulation level, which depends on the distance from players
+
+	sync.Mutex
+}
+
+// NewCreep returns a new creep of the provided type. Creeps other than torches
+// are placed at a random spawn location.
+func NewCreep(creepType int, l *Level) *Creep {
+	var x, y float64
+	if !CreepDefinitions[creepType].Static {
+		x, y = l.NewSpawnLocation()
+	}
+	return newCreepAt(creepType, l, x, y)
+}
+
+// newCreepAt returns a new creep of the provided type at a position.
+func newCreepAt(creepType int, l *Level, x, y float64) *Creep {
+	def := CreepDefinitions[creepType]
+
+	frames := len(def.Sprites)
+
+	startingFrame := 0
+	if frames > 1 {
+		startingFrame = l.rng.Intn(frames)
+	}
+
+	c := &Creep{
+		CreepType: creepType,
+		X:         x,
+		Y:         y,
+		Frames:    frames,
+		Frame:     startingFrame,
+		level:     l,
+		player:    l.NearestPlayer(x, y),
+		rng:       l.rng,
+		Health:    def.Health,
+		cell:      -1,
+	}
+	if creepType == TypeBat {
+		// Roost for a while after spawning.
+		c.State = BatRoosting
+		c.nextAction = 288 + l.rng.Intn(1152)
+	}
+	return c
+}
+
+func (c *Creep) queueNextAction() {
+	c.tick = 0
+	c.seeking = false
+	def := c.Definition()
+	c.nextAction = def.ActionMin + c.rng.Intn(def.ActionRange)
+}
+
+func (c *Creep) runAway() {
+	c.queueNextAction()
+
+	randMovementA := ((c.rng.Float64() - 0.5) * c.moveSpeed()) / 8
+	randMovementB := ((c.rng.Float64() - 0.5) * c.moveSpeed()) / 8
+
+	c.moveX = c.X - c.player.X
+	if c.moveX < 0 {
+		c.moveX = math.Abs(randMovementA) * -1
+	} else {
+		c.moveX = math.Abs(randMovementA)
+	}
+	c.moveY = c.Y - c.player.Y
+	if c.moveY < 0 {
+		c.moveY = math.Abs(randMovementB) * -1
+	} else {
+		c.moveY = math.Abs(randMovementB)
+	}
+}
+
+func (c *Creep) moveSpeed() float64 {
+	def := c.Definition()
+	speed := def.Speed + (float64(c.level.Num) * def.SpeedPerLevel)
+	if def.Feeding != nil {
+		speed += float64(c.SoulsEaten) * def.Feeding.Speed
+	}
+	return speed
+}
+
+func (c *Creep) seekPlayer() {
+	c.steer()
+
+	c.tick = 0
+	c.nextAction = 1440
+	c.seeking = true
+}
+
+// steer points the creep toward the next tile on the path to the nearest
+// player, or directly at its player when there is no path.
+func (c *Creep) steer() {
+	maxSpeed := c.moveSpeed() / 9 * c.Definition().SeekSpeed
+	minSpeed := c.moveSpeed() / 5 / 9
+
+	tx, ty, ok := c.level.PathTarget(c.X, c.Y)
+	if !ok {
+		tx, ty = c.player.X, c.player.Y
+	}
+
+	a := Angle(c.X, c.Y, tx, ty)
+	c.moveX = -math.Cos(a)
+	c.moveY = -math.Sin(a)
+	for {
+		if (c.moveX < -minSpeed || c.moveX > minSpeed) || (c.moveY < -minSpeed || c.moveY > minSpeed) {
+			break
+		}
+
+		c.moveX *= 1.1
+		c.moveY *= 1.1
+	}
+	for {
+		if c.moveX >= -maxSpeed && c.moveX <= maxSpeed && c.moveY >= -maxSpeed && c.moveY <= maxSpeed {
+			break
+		}
+
+		c.moveX *= 0.9
+		c.moveY *= 0.9
+	}
+}
+
+func (c *Creep) doNextAction() {
+	c.queueNextAction()
+
+	randMovementA := ((c.rng.Float64() - 0.5) * c.moveSpeed()) / 12
+	randMovementB := ((c.rng.Float64() - 0.5) * c.moveSpeed()) / 12
+
+	if c.CreepType == TypeGhost {
+		c.facePlayer()
+	}
+
+	repelled := c.repelled()
+	if !repelled && c.rng.Intn(13) == 0 && !c.Definition().Collectable {
+		c.seekPlayer()
+	} else {
+		c.moveX = randMovementA
+		c.moveY = randMovementB
+	}
+
+	if c.X <= 2 && c.moveX < 0 {
+		c.moveX *= 1
+	} else if c.X >= float64(c.level.W-3) && c.moveX > 0 {
+		c.moveX *= 1
+	}
+	if c.Y <= 2 && c.moveY > 0 {
+		c.moveY *= 1
+	} else if c.Y >= float64(c.level.H-3) && c.moveY < 0 {
+		c.moveY *= 1
+	}
+}
+
+func (c *Creep) repelled() bool {
+	if c.Definition().Collectable {
+		return false
+	}
+	return c.player.repels()
+}
+
+func (c *Creep) Update() {
+	c.Lock()
+	defer c.Unlock()
+
+	if c.Health == 0 {
+		return
+	}
+
+	def := c.Definition()
+	if def.Static {
+		return
+	}
+
+	// Target the nearest living player.
+	p := c.level.NearestPlayer(c.X, c.Y)
+	if p == nil {
+		return
+	}
+	c.player = p
+
+	c.tick++
+
+	if c.CreepType == TypeBat && !c.updateBat() {
+		return
+	}
+
+	repelled := c.repelled()
+
+	dx, dy := DeltaXY(c.X, c.Y, c.player.X, c.player.Y)
+	seekDistance := def.SeekRadius
+	if c.stagger > 0 {
+		c.staggerStep()
+	} else if c.CreepType == TypeGhost {
+		// Ghosts only move while no player is looking at them.
+		if c.observed() {
+			return
+		}
+		c.haunt(repelled)
+	} else if c.boss != nil {
+		c.moveBoss()
+	} else if def.Collectable {
+		if dx < seekDistance && dy < seekDistance {
+			c.queueNextAction()
+			c.seekPlayer()
+		} else if c.tick >= c.nextAction {
+			c.doNextAction()
+			c.tick = 0
+		} else if c.seeking {
+			c.steer()
+		}
+	} else if c.updateAwareness(repelled) {
+		// Hunting or investigating.
+	} else if c.seekSoul() {
+		// Feeding.
+	} else if c.tick >= c.nextAction {
+		if c.following() {
+			c.followLeader()
+		} else {
+			c.doNextAction()
+		}
+		c.tick = 0
+	} else if c.seeking {
+		c.steer()
+	}
+
+	flockX, flockY := c.flock()
+	if !c.move(c.X+c.moveX+flockX, c.Y+c.moveY+flockY) {
+		return
+	}
+
+	if repelled {
+		dx, dy := DeltaXY(c.X, c.Y, c.player.X, c.player.Y)
+		if dx <= 3 && dy <= 3 {
+			c.runAway()
+		}
+	}
+
+	// Avoid garlic.
+	c.level.itemBuf = c.level.grid.queryItems(c.X, c.Y, 2, c.level.itemBuf)
+	for _, item := range c.level.itemBuf {
+		if item.Health == 0 || item.ItemType != ItemTypeGarlic {
+			continue
+		}
+
+		dx, dy := DeltaXY(c.X, c.Y, item.X, item.Y)
+		if dx <= 2 && dy <= 2 {
+			c.runAway()
+		}
+	}
+}
+
+// move moves the creep to a position, sliding along walls in the way. It
+// returns false when the creep is unable to move.
+func (c *Creep) move(x, y float64) bool {
+	if c.level.IsFloor(x, y) {
+		c.X, c.Y = x, y
+	} else if c.level.IsFloor(x, c.Y) {
+		c.X = x
+		c.moveY *= -1
+	} else if c.level.IsFloor(c.X, y) {
+		c.Y = y
+		c.moveX *= -1
+	} else {
+		c.nextAction = 0
+		return false
+	}
+	return true
+}
+
+func (c *Creep) Position() (float64, float64) {
+	c.Lock()
+	defer c.Unlock()
+	return c.X, c.Y
+}
+
+// animate advances the creep's animation frame.
+func (c *Creep) animate() {
+	if c.Frames <= 1 || c.level.clock.Since(c.LastFrame) < 75*time.Millisecond {
+		return
+	}
+	c.LastFrame = c.level.clock.Now()
+	c.Frame++
+	if c.Frame == c.Frames {
+		c.Frame = 0
+		if c.CreepType == TypeBat {
+			c.batAnimationEnded()
+		}
+	}
+}
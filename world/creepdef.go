@@ -0,0 +1,143 @@
+package world
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+//go:embed creeps.json
+var creepsJSON []byte
+
+// CreepDefinition holds the properties of a type of creep. Definitions are
+// loaded from creeps.json, where the position of each definition is its type.
+type CreepDefinition struct {
+	Name string
+
+	Sprites        []string // Names of the animation frames
+	FlippedSprites []string // Names of the animation frames while facing left
+	DeadSprite     string   // Name of the sprite of a static creep which was destroyed
+
+	Health int
+
+	Speed         float64 // Movement speed on the first level
+	SpeedPerLevel float64 // Movement speed added on each following level
+	SeekSpeed     float64 // Maximum speed multiplier while seeking a player
+
+	SeekRadius  float64 // Distance at which seen players are hunted
+	SightRadius float64 // Distance at which brightly lit players are seen
+	BiteRadius  float64 // Distance at which players are bitten or collect the creep
+
+	// Number of ticks between random actions.
+	ActionMin   int
+	ActionRange int
+
+	// Points awarded when the creep is killed, which are multiplied by the
+	// level number, or when it is collected.
+	Score int
+
+	Alpha float64    // Opacity when drawn
+	Tint  [3]float64 // Color scale of each channel when drawn, or zero to draw untinted
+
+	HitFlashTicks int      // Ticks the creep flashes after being hit
+	StaggerTicks  int      // Ticks the creep is knocked back and unable to bite after being hit
+	Knockback     float64  // Distance the creep is knocked back when hit
+	HitSounds     []string // Names of the sounds played when hit, chosen at random
+	HitVolume     float64
+
+	DieSounds []string // Names of the sounds played when killed, chosen at random
+	DieVolume float64
+
+	AmbientSound  string     // Name of the sound played occasionally near players
+	AmbientVolume float64    // Volume of the ambient sound
+	AmbientRange  [2]float64 // Distance from players at which the ambient sound is played on each axis
+
+	DropsSoul   bool // Whether a soul is released when the creep is killed
+	Collectable bool // Whether players collect the creep instead of being bitten
+	Static      bool // Whether the creep never moves and remains after being destroyed
+	Boss        bool // Whether the creep may guard an exit, which opens once it is killed
+
+	Flocking *flocking
+	Ranged   *rangedAttack // Projectiles fired at players, if any
+	Feeding  *feeding      // Souls eaten by the creep, if any
+}
+
+// CreepDefinitions are the definitions of each type of creep, indexed by type.
+var CreepDefinitions []*CreepDefinition
+
+// builtinCreepTypes are the creep types which are referred to directly, by the
+// name of their definition.
+var builtinCreepTypes = map[string]*int{
+	"vampire":     &TypeVampire,
+	"bat":         &TypeBat,
+	"ghost":       &TypeGhost,
+	"soul":        &TypeSoul,
+	"torch":       &TypeTorch,
+	"vampirelord": &TypeVampireLord,
+	"bloodmage":   &TypeBloodMage,
+}
+
+func init() {
+	err := json.Unmarshal(creepsJSON, &CreepDefinitions)
+	if err != nil {
+		panic(fmt.Sprintf("failed to load creep definitions: %s", err))
+	}
+	for i, def := range CreepDefinitions {
+		err = def.validate()
+		if err != nil {
+			panic(fmt.Sprintf("failed to load creep definitions: %s (type %d) %s", def.Name, i, err))
+		}
+	}
+	for name, creepType := range builtinCreepTypes {
+		var ok bool
+		*creepType, ok = creepTypeByName(name)
+		if !ok {
+			panic(fmt.Sprintf("failed to load creep definitions: %s is not defined", name))
+		}
+	}
+}
+
+// validate returns an error when the definition is invalid.
+func (def *CreepDefinition) validate() error {
+	if len(def.Sprites) == 0 {
+		return errors.New("has no sprites")
+	}
+	if def.Health <= 0 || def.Score < 0 {
+		return errors.New("has invalid health or score")
+	}
+	if def.Speed < 0 || def.SpeedPerLevel < 0 || def.SeekSpeed < 0 {
+		return errors.New("has invalid speed")
+	}
+	if def.SeekRadius < 0 || def.SightRadius < 0 || def.BiteRadius < 0 {
+		return errors.New("has invalid radius")
+	}
+	// Static creeps never act.
+	if def.ActionMin < 0 || (def.ActionRange <= 0 && !def.Static) {
+		return errors.New("has invalid action interval")
+	}
+	if def.Alpha < 0 || def.Alpha > 1 {
+		return errors.New("has invalid alpha")
+	}
+	if def.HitFlashTicks < 0 || def.StaggerTicks < 0 || def.Knockback < 0 {
+		return errors.New("has invalid hit reaction")
+	}
+	if def.HitVolume < 0 || def.DieVolume < 0 || def.AmbientVolume < 0 {
+		return errors.New("has invalid volume")
+	}
+	if f := def.Flocking; f != nil && f.Radius <= 0 {
+		return errors.New("has invalid flocking radius")
+	}
+	if r := def.Ranged; r != nil && (r.Range <= 0 || r.Distance < 0 || r.Cooldown <= 0 || r.Speed <= 0 || r.Volume < 0) {
+		return errors.New("has invalid ranged attack")
+	}
+	if f := def.Feeding; f != nil && (f.Radius <= 0 || f.MaxSouls < 0 || f.Health < 0 || f.Speed < 0) {
+		return errors.New("has invalid feeding")
+	}
+	return nil
+}
+
+// Definition returns the definition of the creep's type.
+func (c *Creep) Definition() *CreepDefinition {
+	return CreepDefinitions[c.CreepType]
+}
@@ -0,0 +1,293 @@
+package world
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Difficulty levels.
+const (
+	DifficultyEasy = iota
+	DifficultyNormal
+	DifficultyHard
+)
+
+const (
+	// pressureRadius is the distance from players within which creeps add to
+	// the pressure players are under.
+	pressureRadius = 8
+
+	// pressureDamage and pressureCreeps are the recent damage and the number
+	// of nearby creeps at which players are under the most pressure.
+	pressureDamage = 3
+	pressureCreeps = 12
+
+	// damageDecay is the fraction of the recent damage taken by players which
+	// is remembered after each tick.
+	damageDecay = 0.999
+
+	// killMemory is the time after a kill during which players are considered
+	// to be fighting.
+	killMemory = 10 * time.Second
+
+	// killPressure is the pressure players are under immediately after a
+	// kill.
+	killPressure = 0.5
+
+	// Distances from a player at which waves spawn, beyond the edges of the
+	// screen at the default zoom level.
+	waveSpawnMin = 16
+	waveSpawnMax = 28
+
+	// maxBatWave is the maximum number of bats spawned in a single wave.
+	maxBatWave = 12
+)
+
+// spawnConfig configures the spawning of creeps on a level, which is part of
+// its definition.
+type spawnConfig struct {
+	StartingCreeps int // Vampires placed when the level is generated
+	MaxCreeps      int // Living creeps above which waves are not spawned
+
+	WaveInterval int     // Ticks between waves while players are under no pressure
+	WaveSize     int     // Creeps in the first wave
+	WaveGrowth   int     // Ticks after which waves grow by one creep
+	MaxWaveSize  int     // Maximum creeps in a wave
+	BatChance    float64 // Chance of a wave being made of bats
+	Mages        int     // Blood mages leading each wave of vampires
+
+	GhostInterval int // Ticks between ghost spawns
+	MaxGhosts     int // Maximum ghosts spawned at once
+
+	PressureLimit float64 // Pressure at which waves are held back
+}
+
+// difficultyConfig scales the spawning of creeps.
+type difficultyConfig struct {
+	Creeps   float64 // Multiplier of the number of creeps spawned
+	Interval float64 // Multiplier of the time between waves
+	Pressure float64 // Pressure at which waves are held back
+}
+
+var difficulties = []difficultyConfig{
+	DifficultyEasy: {
+		Creeps:   0.66,
+		Interval: 1.5,
+		Pressure: 0.5,
+	},
+	DifficultyNormal: {
+		Creeps:   1,
+		Interval: 1,
+		Pressure: 0.7,
+	},
+	DifficultyHard: {
+		Creeps:   1.5,
+		Interval: 0.66,
+		Pressure: 0.9,
+	},
+}
+
+// spawnSchedule returns the spawn configuration of a level at a difficulty.
+func spawnSchedule(def *LevelDefinition, difficulty int) spawnConfig {
+	cfg := def.Spawn
+
+	if difficulty < 0 || difficulty >= len(difficulties) {
+		difficulty = DifficultyNormal
+	}
+	d := difficulties[difficulty]
+
+	scale := func(v int, m float64) int {
+		scaled := int(math.Round(float64(v) * m))
+		if scaled < 1 {
+			scaled = 1
+		}
+		return scaled
+	}
+	cfg.StartingCreeps = scale(cfg.StartingCreeps, d.Creeps)
+	cfg.MaxCreeps = scale(cfg.MaxCreeps, d.Creeps)
+	cfg.WaveSize = scale(cfg.WaveSize, d.Creeps)
+	cfg.MaxWaveSize = scale(cfg.MaxWaveSize, d.Creeps)
+	cfg.WaveInterval = scale(cfg.WaveInterval, d.Interval)
+	cfg.GhostInterval = scale(cfg.GhostInterval, d.Interval)
+	cfg.PressureLimit = d.Pressure
+	return cfg
+}
+
+// director spawns creeps in waves. Waves are held back while players are under
+// pressure, and arrive sooner while players are not.
+type director struct {
+	Ticks int // Ticks since the level started
+
+	Damage   float64 // Recent damage taken by players, decaying over time
+	Nearby   int     // Living creeps near players
+	LastKill time.Time
+
+	NextWave float64 // Ticks until the next wave
+}
+
+// pressure returns how hard pressed players are, from 0 to 1. Pressure is
+// the greatest of the recent damage taken by players, the number of creeps
+// near them and how recently they killed a creep.
+func (d *director) pressure(now time.Time) float64 {
+	p := math.Max(math.Min(1, d.Damage/pressureDamage), math.Min(1, float64(d.Nearby)/pressureCreeps))
+	if !d.LastKill.IsZero() {
+		if since := now.Sub(d.LastKill); since < killMemory {
+			p = math.Max(p, (1-float64(since)/float64(killMemory))*killPressure)
+		}
+	}
+	return p
+}
+
+// Pressure returns how hard pressed the players are by creeps, from 0 to 1.
+func (w *World) Pressure() float64 {
+	return w.director.pressure(w.Clock.Now())
+}
+
+// spawnConfig returns the spawn configuration of the current level.
+func (w *World) spawnConfig() spawnConfig {
+	return spawnSchedule(w.LevelDefinition(), w.Difficulty)
+}
+
+// direct spawns ghosts and waves of creeps during a single tick.
+func (w *World) direct() {
+	d := &w.director
+	cfg := w.spawnConfig()
+
+	d.Ticks++
+	d.Damage *= damageDecay
+
+	if w.Level.LiveCreeps >= cfg.MaxCreeps {
+		return
+	}
+
+	// Spawn ghosts.
+	if d.Ticks%cfg.GhostInterval == 0 {
+		spawnAmount := d.Ticks / cfg.GhostInterval
+		if spawnAmount > cfg.MaxGhosts {
+			spawnAmount = cfg.MaxGhosts
+		}
+		w.addEvent(Event{EventType: EventMessage, Message: fmt.Sprintf("SPAWN %d GHOSTS", spawnAmount)})
+		for i := 0; i < spawnAmount; i++ {
+			w.Level.AddCreep(TypeGhost)
+		}
+	}
+
+	// Hold back while players are under pressure.
+	pressure := d.pressure(w.Clock.Now())
+	if pressure >= cfg.PressureLimit {
+		return
+	}
+	d.NextWave -= 2 - pressure/cfg.PressureLimit
+	if d.NextWave > 0 {
+		return
+	}
+	d.NextWave = float64(cfg.WaveInterval)
+
+	w.spawnWave(cfg)
+}
+
+// spawnWave spawns a wave of vampires or bats together out of sight of the
+// players.
+func (w *World) spawnWave(cfg spawnConfig) {
+	l := w.Level
+
+	spawnAmount := cfg.WaveSize + w.director.Ticks/cfg.WaveGrowth
+	if spawnAmount > cfg.MaxWaveSize {
+		spawnAmount = cfg.MaxWaveSize
+	}
+	// Ensure there are enough souls to rescue.
+	if l.LiveCreeps < l.RequiredSouls*2 {
+		spawnAmount *= 4
+	}
+	if spawnAmount > cfg.MaxCreeps-l.LiveCreeps {
+		spawnAmount = cfg.MaxCreeps - l.LiveCreeps
+	}
+
+	x, y := l.waveSpawnLocation()
+
+	if w.rng.Float64() >= cfg.BatChance {
+		w.addEvent(Event{EventType: EventMessage, Message: fmt.Sprintf("SPAWN %d VAMPIRES", spawnAmount)})
+		for i := 0; i < spawnAmount; i++ {
+			creepType := TypeVampire
+			if i < cfg.Mages {
+				creepType = TypeBloodMage
+			}
+			l.placeCreep(l.addCreepAt(creepType, x, y), x, y)
+		}
+		return
+	}
+
+	if spawnAmount > maxBatWave {
+		spawnAmount = maxBatWave
+	}
+	w.addEvent(Event{EventType: EventMessage, Message: fmt.Sprintf("SPAWN %d BATS", spawnAmount)})
+	if spawnAmount < minSwarmSize {
+		for i := 0; i < spawnAmount; i++ {
+			l.placeCreep(l.addCreepAt(TypeBat, x, y), x, y)
+		}
+		return
+	}
+
+	swarm := l.AddSwarm(spawnAmount)
+	leader := swarm[0]
+	leaderX, leaderY := leader.X, leader.Y
+	l.placeCreep(leader, x, y)
+	for _, c := range swarm[1:] {
+		cx, cy := c.X-leaderX+leader.X, c.Y-leaderY+leader.Y
+		if !l.IsFloor(cx, cy) {
+			cx, cy = leader.X, leader.Y
+		}
+		l.moveCreep(c, cx, cy)
+		c.player = leader.player
+	}
+}
+
+// waveSpawnLocation returns a random floor position near a random living
+// player, beyond the sight of all players.
+func (l *Level) waveSpawnLocation() (float64, float64) {
+	var alive []*Player
+	for _, p := range l.Players {
+		if p.Health > 0 {
+			alive = append(alive, p)
+		}
+	}
+	if len(alive) == 0 {
+		return l.NewSpawnLocation()
+	}
+
+SPAWNLOCATION:
+	for i := 0; i < 100; i++ {
+		p := alive[l.rng.Intn(len(alive))]
+		a := l.rng.Float64() * 2 * math.Pi
+		distance := waveSpawnMin + l.rng.Float64()*(waveSpawnMax-waveSpawnMin)
+		x, y := math.Round(p.X+math.Cos(a)*distance), math.Round(p.Y+math.Sin(a)*distance)
+		if !l.IsFloor(x, y) {
+			continue
+		}
+
+		// Too close to a player.
+		for _, other := range alive {
+			dx, dy := x-other.X, y-other.Y
+			if dx*dx+dy*dy < waveSpawnMin*waveSpawnMin {
+				continue SPAWNLOCATION
+			}
+		}
+		return x, y
+	}
+	return l.NewSpawnLocation()
+}
+
+// placeCreep moves a creep which was just added to a floor position near the
+// provided position, and targets the player nearest to it.
+func (l *Level) placeCreep(c *Creep, x, y float64) {
+	for i := 0; i < 8; i++ {
+		cx, cy := x+float64(l.rng.Intn(5)-2), y+float64(l.rng.Intn(5)-2)
+		if l.IsFloor(cx, cy) {
+			x, y = cx, cy
+			break
+		}
+	}
+	l.moveCreep(c, x, y)
+	c.player = l.NearestPlayer(x, y)
+}
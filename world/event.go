@@ -0,0 +1,36 @@
+package world
+
+const (
+	EventFire = iota
+	EventCreepFire
+	EventCreepKilled
+	EventCreepHit
+	EventPlayerHurt
+	EventPlayerDied
+	EventPickup
+	EventSoulEaten
+	EventCreepSound
+	EventExitOpen
+	EventLevelComplete
+	EventWin
+	EventMessage
+	EventCheat
+)
+
+// Event represents something which happened during a tick and which may be
+// of interest outside of the simulation, such as a sound being played.
+type Event struct {
+	EventType int
+
+	X, Y float64
+
+	Creep  *Creep
+	Item   *Item
+	Player *Player // Player affected by the event, if any
+
+	Message string
+}
+
+func (w *World) addEvent(e Event) {
+	w.Events = append(w.Events, e)
+}
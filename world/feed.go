@@ -0,0 +1,82 @@
+package world
+
+import (
+	"math"
+)
+
+// soulSearchTicks is the number of ticks between searches for souls by each
+// creep which feeds on them.
+const soulSearchTicks = 36
+
+// feeding holds how a type of creep feeds on souls, which is part of its
+// definition. Each soul eaten makes the creep stronger.
+type feeding struct {
+	Radius   float64 // Distance at which souls are sought
+	MaxSouls int     // Souls eaten before the creep is sated
+
+	Health int     // Health gained from each soul
+	Speed  float64 // Movement speed gained from each soul
+}
+
+// seekSoul moves a creep which feeds on souls toward the nearest soul. It
+// returns whether the creep is moving toward a soul.
+func (c *Creep) seekSoul() bool {
+	f := c.Definition().Feeding
+	if f == nil || c.SoulsEaten >= f.MaxSouls {
+		c.prey = nil
+		return false
+	}
+
+	if c.prey != nil {
+		dx, dy := DeltaXY(c.X, c.Y, c.prey.X, c.prey.Y)
+		if c.prey.Health == 0 || dx > f.Radius || dy > f.Radius {
+			c.prey = nil
+		}
+	}
+	if c.prey == nil && (c.level.clock.Tick()+c.id)%soulSearchTicks == 0 {
+		var nearest float64
+		l := c.level
+		l.creepBuf = l.grid.queryCreeps(c.X, c.Y, f.Radius, l.creepBuf)
+		for _, soul := range l.creepBuf {
+			if soul.CreepType != TypeSoul || soul.Health == 0 {
+				continue
+			}
+			dx, dy := soul.X-c.X, soul.Y-c.Y
+			distance := dx*dx + dy*dy
+			if distance <= f.Radius*f.Radius && (c.prey == nil || distance < nearest) {
+				c.prey, nearest = soul, distance
+			}
+		}
+	}
+	if c.prey == nil {
+		return false
+	}
+
+	c.queueNextAction()
+	a := Angle(c.prey.X, c.prey.Y, c.X, c.Y)
+	speed := c.moveSpeed() / 9
+	c.moveX, c.moveY = math.Cos(a)*speed, math.Sin(a)*speed
+	return true
+}
+
+// feed lets a creep eat the soul it is seeking once it reaches it. Eaten
+// souls are lost to players.
+func (w *World) feed(c *Creep) {
+	soul := c.prey
+	if soul == nil || soul.Health == 0 {
+		return
+	}
+	def := c.Definition()
+	dx, dy := DeltaXY(c.X, c.Y, soul.X, soul.Y)
+	if dx > def.BiteRadius || dy > def.BiteRadius {
+		return
+	}
+
+	w.HurtCreep(soul, -1, nil)
+	c.prey = nil
+	c.SoulsEaten++
+	c.Health += def.Feeding.Health
+	w.SoulsEaten++
+
+	w.addEvent(Event{EventType: EventSoulEaten, X: c.X, Y: c.Y, Creep: c})
+}
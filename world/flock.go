@@ -0,0 +1,142 @@
+package world
+
+import (
+	"math"
+)
+
+// minSwarmSize is the minimum number of bats spawned at once which form a
+// swarm.
+const minSwarmSize = 4
+
+// flocking holds the weights of the steering behaviours of a type of creep,
+// which keep groups of creeps from overlapping and move them as a crowd. The
+// weights of each type of creep are part of its definition.
+type flocking struct {
+	Radius float64 // Distance within which other creeps are neighbors
+
+	Separation float64 // Steering away from neighbors
+	Alignment  float64 // Steering toward the average movement of neighbors
+	Cohesion   float64 // Steering toward the average position of neighbors
+}
+
+// swarmFlocking are the flocking weights of bats following a swarm leader.
+// Alignment and cohesion are relative to the leader rather than to neighbors.
+var swarmFlocking = flocking{
+	Radius:     1,
+	Separation: 0.02,
+	Alignment:  0.25,
+	Cohesion:   0.01,
+}
+
+// AddSwarm adds a swarm of bats to the Level. The first bat leads the swarm
+// and the others follow it in formation.
+func (l *Level) AddSwarm(size int) []*Creep {
+	leader := l.AddCreep(TypeBat)
+	leader.setState(BatFlying)
+	swarm := []*Creep{leader}
+	for i := 1; i < size; i++ {
+		c := NewCreep(TypeBat, l)
+		c.X, c.Y = leader.X, leader.Y
+
+		// Surround the leader.
+		a := float64(i) / float64(size-1) * 2 * math.Pi
+		if x, y := leader.X+math.Cos(a)*0.75, leader.Y+math.Sin(a)*0.75; l.IsFloor(x, y) {
+			c.X, c.Y = x, y
+		}
+
+		c.leader = leader
+		c.setState(BatFlying)
+		leader.Followers++
+		l.addCreep(c)
+		swarm = append(swarm, c)
+	}
+	return swarm
+}
+
+// following returns whether the creep is following a living swarm leader.
+func (c *Creep) following() bool {
+	if c.leader != nil && c.leader.Health <= 0 {
+		c.leader = nil
+	}
+	return c.leader != nil
+}
+
+// followLeader matches the movement of the swarm leader.
+func (c *Creep) followLeader() {
+	c.queueNextAction()
+	c.moveX, c.moveY = c.leader.moveX, c.leader.moveY
+}
+
+// leaveSwarm removes a creep which died from its swarm.
+func (c *Creep) leaveSwarm() {
+	if c.leader != nil {
+		c.leader.Followers--
+		c.leader = nil
+	}
+}
+
+// flock returns the movement of the creep resulting from its flocking
+// behaviours, in addition to its own movement.
+func (c *Creep) flock() (float64, float64) {
+	f := c.Definition().Flocking
+	following := c.following()
+	if following {
+		f = &swarmFlocking
+	} else if f == nil {
+		return 0, 0
+	}
+
+	var sepX, sepY, alignX, alignY, centerX, centerY float64
+	var neighbors int
+
+	l := c.level
+	l.creepBuf = l.grid.queryCreeps(c.X, c.Y, f.Radius, l.creepBuf)
+	for _, n := range l.creepBuf {
+		if n == c || n.Health == 0 || n.CreepType != c.CreepType {
+			continue
+		}
+
+		dx, dy := c.X-n.X, c.Y-n.Y
+		d := math.Sqrt(dx*dx + dy*dy)
+		if d >= f.Radius {
+			continue
+		}
+
+		// Push creeps apart more strongly the closer they are. Creeps at the
+		// same position are pushed in a direction based on the order they
+		// were added to the Level.
+		push := (f.Radius - d) / f.Radius
+		if d == 0 {
+			a := float64(c.id)
+			sepX += math.Cos(a) * push
+			sepY += math.Sin(a) * push
+		} else {
+			sepX += dx / d * push
+			sepY += dy / d * push
+		}
+
+		alignX += n.moveX
+		alignY += n.moveY
+		centerX += n.X
+		centerY += n.Y
+		neighbors++
+	}
+
+	if following {
+		alignX, alignY = c.leader.moveX, c.leader.moveY
+		centerX, centerY = c.leader.X, c.leader.Y
+		neighbors = 1
+	} else if neighbors == 0 {
+		return 0, 0
+	}
+
+	sx := sepX*f.Separation + (alignX/float64(neighbors)-c.moveX)*f.Alignment + (centerX/float64(neighbors)-c.X)*f.Cohesion
+	sy := sepY*f.Separation + (alignY/float64(neighbors)-c.moveY)*f.Alignment + (centerY/float64(neighbors)-c.Y)*f.Cohesion
+
+	// Limit flocking to the speed of the creep.
+	maxSpeed := c.moveSpeed() / 9
+	if s := math.Sqrt(sx*sx + sy*sy); s > maxSpeed {
+		sx, sy = sx/s*maxSpeed, sy/s*maxSpeed
+	}
+	return sx, sy
+}
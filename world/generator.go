@@ -0,0 +1,126 @@
+package world
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/Meshiest/go-dungeon/dungeon"
+)
+
+const dungeonScale = 4
+
+// Names of the level generators.
+const (
+	GeneratorDungeon = "dungeon"
+	GeneratorCave    = "cave"
+	GeneratorBSP     = "bsp"
+)
+
+// LevelGenerators are the names of the available level generators.
+var LevelGenerators = []string{GeneratorDungeon, GeneratorCave, GeneratorBSP}
+
+// LevelGenerator generates the layout of levels. Walls, torches, the entrance,
+// the exit and the lightmap are added to the layout by NewLevel.
+type LevelGenerator interface {
+	// Generate returns a new layout of the provided size. All random
+	// decisions are made using the provided source.
+	Generate(w, h int, rng *rand.Rand) (*LevelLayout, error)
+}
+
+// LevelLayout is a layout of floor tiles produced by a LevelGenerator.
+type LevelLayout struct {
+	W, H int
+
+	Floor [][]bool // (Y,X) array of whether each tile is floor
+
+	// Preferred positions of the entrance and the exit, which are the left
+	// tiles of doors in a bottom wall and a top wall respectively. Any
+	// suitable wall is used when no preferred position is suitable.
+	Entrances [][2]int
+	Exits     [][2]int
+}
+
+// newLevelLayout returns a new LevelLayout without any floor.
+func newLevelLayout(w, h int) *LevelLayout {
+	layout := &LevelLayout{
+		W:     w,
+		H:     h,
+		Floor: make([][]bool, h),
+	}
+	for y := range layout.Floor {
+		layout.Floor[y] = make([]bool, w)
+	}
+	return layout
+}
+
+// NewLevelGenerator returns the named level generator, configured for a level.
+// The generator of the level is returned when no name is provided, and the
+// dungeon generator is returned when the level does not specify a generator.
+func NewLevelGenerator(name string, def *LevelDefinition) (LevelGenerator, error) {
+	if name == "" {
+		name = def.Generator
+	}
+	switch name {
+	case "", GeneratorDungeon:
+		return &DungeonGenerator{Rooms: def.Rooms}, nil
+	case GeneratorCave:
+		return &CaveGenerator{
+			FillChance: 0.45,
+			Steps:      5,
+			Scale:      2,
+		}, nil
+	case GeneratorBSP:
+		return &BSPGenerator{
+			MinRoomSize:   6,
+			CorridorWidth: 2,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown level generator %s", name)
+	}
+}
+
+// DungeonGenerator generates levels of rectangular rooms connected by winding
+// corridors using go-dungeon. Levels must be square, and their size must be
+// divisible by the dungeon scale (4).
+type DungeonGenerator struct {
+	Rooms int
+}
+
+// Generate returns a new layout of the provided size.
+func (g *DungeonGenerator) Generate(w, h int, rng *rand.Rand) (*LevelLayout, error) {
+	if w != h || w%dungeonScale != 0 {
+		return nil, fmt.Errorf("invalid dungeon size %dx%d", w, h)
+	}
+
+	d := newDungeon(w/dungeonScale, g.Rooms, rng)
+	dungeonFloor := 1
+	layout := newLevelLayout(w, h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			layout.Floor[y][x] = d.Grid[x/dungeonScale][y/dungeonScale] == dungeonFloor
+		}
+	}
+	return layout, nil
+}
+
+// newDungeon returns a new dungeon layout generated using the provided source.
+// dungeon.NewDungeon always seeds its own source with the current time.
+func newDungeon(size, rooms int, rng *rand.Rand) *dungeon.Dungeon {
+	d := &dungeon.Dungeon{
+		Size:     size,
+		NumRooms: rooms,
+		Grid:     make([][]int, size),
+		NumTries: 30,
+		MinSize:  3,
+		MaxSize:  12,
+		Rooms:    []dungeon.Rectangle{},
+		Regions:  []int{},
+		Bounds:   dungeon.Rectangle{X: 1, Y: 1, Width: size - 2, Height: size - 2},
+		Rand:     rng,
+	}
+	for i := 0; i < size; i++ {
+		d.Grid[i] = make([]int, size)
+	}
+	d.Generate()
+	return d
+}
@@ -0,0 +1,67 @@
+package world
+
+import (
+	"math"
+)
+
+// ghostViewCone is half of the angle in radians within which a player sees a
+// ghost in front of them.
+const ghostViewCone = math.Pi / 4
+
+// angleDifference returns the smallest difference between two angles in
+// radians, which is between 0 and Pi.
+func angleDifference(a, b float64) float64 {
+	d := math.Mod(a-b, 2*math.Pi)
+	if d < 0 {
+		d += 2 * math.Pi
+	}
+	if d > math.Pi {
+		d = 2*math.Pi - d
+	}
+	return d
+}
+
+// inViewCone returns whether a target is within the view cone of a viewer
+// facing the provided angle.
+func inViewCone(viewerX, viewerY, angle, targetX, targetY, cone float64) bool {
+	if viewerX == targetX && viewerY == targetY {
+		return true
+	}
+	return angleDifference(angle, Angle(targetX, targetY, viewerX, viewerY)) <= cone
+}
+
+// observed returns whether any living player is looking at the ghost.
+func (c *Creep) observed() bool {
+	for _, p := range c.level.Players {
+		if p.Health > 0 && inViewCone(p.X, p.Y, p.Angle, c.X, c.Y, ghostViewCone) {
+			return true
+		}
+	}
+	return false
+}
+
+// facePlayer turns the creep toward its player.
+func (c *Creep) facePlayer() {
+	c.Angle = Angle(c.X, c.Y, c.player.X, c.player.Y)
+
+	c.Flipped = c.Angle > math.Pi/2 || c.Angle < -1*math.Pi/2
+	if c.Flipped {
+		c.Angle = c.Angle - math.Pi
+	}
+}
+
+// haunt moves an unobserved ghost toward its player. Repelled ghosts wander
+// instead.
+func (c *Creep) haunt(repelled bool) {
+	c.facePlayer()
+
+	if repelled {
+		if c.tick >= c.nextAction {
+			c.doNextAction()
+		}
+		return
+	}
+
+	c.seeking = true
+	c.steer()
+}
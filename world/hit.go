@@ -0,0 +1,45 @@
+package world
+
+import (
+	"math"
+)
+
+// shootCreep hurts a creep hit by a projectile. Creeps react to being hit by
+// flashing, being knocked back along the path of the projectile and staggering,
+// as tuned by their definition.
+func (w *World) shootCreep(c *Creep, p *Projectile) {
+	def := c.Definition()
+
+	c.LastHit = w.Clock.Now()
+
+	if def.StaggerTicks > 0 {
+		// Knock the creep back quickly at first, slowing until the stagger
+		// ends, so it travels the knockback distance in total.
+		speed := 2 * def.Knockback / float64(def.StaggerTicks)
+		c.knockX, c.knockY = math.Cos(p.Angle)*speed, math.Sin(p.Angle)*speed
+		c.stagger = def.StaggerTicks
+	}
+
+	w.HurtCreep(c, 1, p.Player)
+
+	if c.Health > 0 {
+		w.addEvent(Event{EventType: EventCreepHit, X: c.X, Y: c.Y, Creep: c, Player: p.Player})
+		if p.Player != nil {
+			c.alert(p.Player.X, p.Player.Y)
+		}
+	}
+}
+
+// Staggered returns whether the creep is recovering from being hit. Staggered
+// creeps are knocked back and do not bite.
+func (c *Creep) Staggered() bool {
+	return c.stagger > 0
+}
+
+// staggerStep moves a staggered creep along its knockback.
+func (c *Creep) staggerStep() {
+	c.stagger--
+	remaining := float64(c.stagger) / float64(c.Definition().StaggerTicks)
+	c.moveX, c.moveY = c.knockX*remaining, c.knockY*remaining
+	c.seeking = false
+}
@@ -0,0 +1,38 @@
+package world
+
+import (
+	"math"
+)
+
+// Input represents the player input during a single tick.
+type Input struct {
+	MoveX, MoveY float64 // Movement in the range -1 to 1
+	Angle        float64 // Aim angle
+	Fire         bool
+	Cheat        int
+}
+
+// Quantize returns the input rounded to the precision stored in a Replay.
+// Inputs must be quantized before they are recorded, so that they may be
+// reproduced exactly when the Replay is played back.
+func (in Input) Quantize() Input {
+	in.MoveX = float64(quantizeMove(in.MoveX)) / moveScale
+	in.MoveY = float64(quantizeMove(in.MoveY)) / moveScale
+	in.Angle = float64(quantizeAngle(in.Angle)) / angleScale
+	return in
+}
+
+const (
+	moveScale  = 100
+	angleScale = 10000
+)
+
+func quantizeMove(v float64) int8 {
+	v = math.Max(-1, math.Min(1, v))
+	return int8(math.Round(v * moveScale))
+}
+
+func quantizeAngle(v float64) int16 {
+	v = math.Remainder(v, 2*math.Pi)
+	return int16(math.Round(v * angleScale))
+}
@@ -0,0 +1,34 @@
+package world
+
+import (
+	"sync"
+)
+
+const (
+	ItemTypeGarlic = iota
+	ItemTypeHolyWater
+)
+
+// Item represents an item which may be picked up by the player.
+type Item struct {
+	X, Y float64
+
+	ItemType int
+
+	level *Level
+
+	Health int
+
+	sync.Mutex
+}
+
+func (item *Item) useScore() int {
+	switch item.ItemType {
+	case ItemTypeGarlic:
+		return 275
+	case ItemTypeHolyWater:
+		return 150
+	default:
+		return 0
+	}
+}
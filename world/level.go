@@ -0,0 +1,577 @@
+package world
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// levelAttempts is the number of layouts generated for a level before giving
+// up, when layouts are unsuitable.
+const levelAttempts = 10
+
+// Level represents a game level.
+type Level struct {
+	Num int
+
+	W, H int
+
+	Tiles    [][]*Tile // (Y,X) array of tiles
+	TileSize int
+
+	TopWalls   [][]*Tile
+	SideWalls  [][]*Tile
+	OtherWalls [][]*Tile
+
+	Items []*Item
+
+	Creeps     []*Creep
+	LiveCreeps int
+
+	// Living creeps which are updated less often or not at all, as they are
+	// far from players.
+	FarCreeps      int
+	SleepingCreeps int
+
+	grid        *spatialHash // Index of creeps and items by position
+	nextCreepID int
+
+	creepBuf []*Creep
+	itemBuf  []*Item
+
+	Players []*Player
+
+	rng   *rand.Rand
+	clock *Clock
+
+	Torches []*Creep
+
+	Boss *Creep // Creep guarding the exit, if any
+
+	flow *flowField
+
+	EnterX, EnterY int
+	ExitX, ExitY   int
+
+	ExitOpenTime time.Time
+
+	RequiredSouls int
+}
+
+// NewLevel returns a new randomly generated Level, using the provided
+// generator to generate its layout. All random decisions are made using the
+// provided source, so the same source state always produces the same Level.
+func NewLevel(levelNum int, gen LevelGenerator, players []*Player, rng *rand.Rand, clock *Clock) (*Level, error) {
+	def := levelDefinition(levelNum)
+	if def == nil {
+		return nil, fmt.Errorf("unknown level %d", levelNum)
+	}
+
+	var err error
+	for i := 0; i < levelAttempts; i++ {
+		var l *Level
+		l, err = newLevel(levelNum, def, gen, players, rng, clock)
+		if err == nil {
+			return l, nil
+		}
+	}
+	return nil, err
+}
+
+// newLevel generates a Level, returning an error when the generated layout is
+// unsuitable.
+func newLevel(levelNum int, def *LevelDefinition, gen LevelGenerator, players []*Player, rng *rand.Rand, clock *Clock) (*Level, error) {
+	l := &Level{
+		Num:           levelNum,
+		W:             def.Size,
+		H:             def.Size,
+		TileSize:      32,
+		RequiredSouls: def.RequiredSouls,
+		Players:       players,
+		rng:           rng,
+		clock:         clock,
+	}
+	l.grid = newSpatialHash(l.W, l.H)
+
+	layout, err := gen.Generate(l.W, l.H, l.rng)
+	if err != nil {
+		return nil, err
+	} else if layout.W != l.W || layout.H != l.H {
+		return nil, errors.New("generated layout size does not match level size")
+	}
+
+	// Tiles at the edges of the level are never floor, leaving room for walls.
+	l.Tiles = make([][]*Tile, l.H)
+	for y := 0; y < l.H; y++ {
+		l.Tiles[y] = make([]*Tile, l.W)
+		for x := 0; x < l.W; x++ {
+			t := &Tile{}
+			edge := x == 0 || y == 0 || x == l.W-1 || y == l.H-1
+			if !edge && layout.Floor[y][x] {
+				if l.rng.Intn(13) == 0 {
+					t.AddSprite(SpriteFloorC)
+				} else {
+					t.AddSprite(SpriteFloorA)
+				}
+				t.Floor = true
+			}
+			l.Tiles[y][x] = t
+		}
+	}
+
+	neighbors := func(x, y int) [][2]int {
+		return [][2]int{
+			{x - 1, y - 1},
+			{x, y - 1},
+			{x + 1, y - 1},
+			{x + 1, y},
+			{x + 1, y + 1},
+			{x, y + 1},
+			{x - 1, y + 1},
+			{x - 1, y},
+		}
+	}
+
+	floorTile := func(x, y int) bool {
+		t := l.Tile(x, y)
+		if t == nil {
+			return false
+		}
+		return t.Floor
+	}
+
+	l.TopWalls = make([][]*Tile, l.H)
+	l.SideWalls = make([][]*Tile, l.H)
+	l.OtherWalls = make([][]*Tile, l.H)
+	for y := 0; y < l.H; y++ {
+		l.TopWalls[y] = make([]*Tile, l.W)
+		l.SideWalls[y] = make([]*Tile, l.W)
+		l.OtherWalls[y] = make([]*Tile, l.W)
+	}
+
+	// Entrance and exit candidates.
+	var topWalls [][2]int
+	var bottomWalls [][2]int
+
+	// Add walls.
+	for x := 0; x < l.W; x++ {
+		for y := 0; y < l.H; y++ {
+			t := l.Tile(x, y)
+			if t == nil {
+				continue
+			}
+			if !t.Floor {
+				continue
+			}
+			for _, n := range neighbors(x, y) {
+				nx, ny := n[0], n[1]
+				neighbor := l.Tile(nx, ny)
+				if neighbor == nil || neighbor.Floor || neighbor.Wall {
+					continue
+				}
+				neighbor.Wall = true
+
+				// From perspective of neighbor tile.
+				bottom := floorTile(nx, ny+1)
+				top := floorTile(nx, ny-1)
+				right := floorTile(nx+1, ny)
+				left := floorTile(nx-1, ny)
+				topLeft := floorTile(nx-1, ny-1)
+				topRight := floorTile(nx+1, ny-1)
+				bottomLeft := floorTile(nx-1, ny+1)
+				bottomRight := floorTile(nx+1, ny+1)
+
+				// Determine which wall sprite to belongs here.
+				spriteTop := !top && bottom
+				spriteLeft := (left || bottomLeft) && !right && !bottomRight && !bottom
+				spriteRight := (right || bottomRight) && !left && !bottomLeft && !bottom
+				spriteBottomRight := !topLeft && !top && topRight && !bottomLeft && !bottom && !bottomRight
+				spriteBottomLeft := topLeft && !top && !topRight && !bottomLeft && !bottom && !bottomRight
+				spriteBottom := top && !bottom
+
+				// Add wall sprite.
+				switch {
+				case spriteTop:
+					if !bottomLeft || !bottomRight || left || right {
+						neighbor.AddSprite(SpriteWallPillar)
+						c := NewCreep(TypeTorch, l)
+						c.X, c.Y = float64(nx), float64(ny)
+						l.addCreep(c)
+						l.Torches = append(l.Torches, c)
+					} else {
+						neighbor.AddSprite(SpriteWallTop)
+
+						farRight := floorTile(nx+2, ny)
+						farBottomRight := floorTile(nx+2, ny+1)
+						if bottomRight && farBottomRight && !right && !farRight && y > 2 {
+							topWalls = append(topWalls, [2]int{nx, ny})
+						}
+					}
+
+					l.TopWalls[ny][nx] = neighbor
+				case spriteLeft:
+					if spriteBottom {
+						neighbor.AddSprite(SpriteWallBottom)
+					}
+					neighbor.AddSprite(SpriteWallLeft)
+
+					l.SideWalls[ny][nx] = neighbor
+					l.OtherWalls[ny][nx] = neighbor
+				case spriteRight:
+					if spriteBottom {
+						neighbor.AddSprite(SpriteWallBottom)
+					}
+					neighbor.AddSprite(SpriteWallRight)
+
+					l.SideWalls[ny][nx] = neighbor
+					l.OtherWalls[ny][nx] = neighbor
+				case spriteBottomLeft:
+					neighbor.AddSprite(SpriteWallBottomLeft)
+
+					l.SideWalls[ny][nx] = neighbor
+					l.OtherWalls[ny][nx] = neighbor
+				case spriteBottomRight:
+					neighbor.AddSprite(SpriteWallBottomRight)
+
+					l.SideWalls[ny][nx] = neighbor
+					l.OtherWalls[ny][nx] = neighbor
+				case spriteBottom:
+					neighbor.AddSprite(SpriteWallBottom)
+
+					l.OtherWalls[ny][nx] = neighbor
+
+					farRight := floorTile(nx+2, ny)
+					farTopRight := floorTile(nx+2, ny-1)
+					if topRight && farTopRight && !right && !farRight && ny < l.H-3 {
+						bottomWalls = append(bottomWalls, [2]int{nx, ny})
+					}
+				}
+			}
+		}
+	}
+
+	bottomWalls = preferredWalls(bottomWalls, layout.Entrances)
+	topWalls = preferredWalls(topWalls, layout.Exits)
+	if len(bottomWalls) == 0 || len(topWalls) == 0 {
+		return nil, errors.New("no suitable walls for the entrance and exit")
+	}
+
+	for i := 0; ; i++ {
+		entrance := bottomWalls[l.rng.Intn(len(bottomWalls))]
+		l.EnterX, l.EnterY = entrance[0], entrance[1]
+
+		exit := topWalls[l.rng.Intn(len(topWalls))]
+		l.ExitX, l.ExitY = exit[0], exit[1]
+
+		dx, dy := DeltaXY(float64(l.EnterX), float64(l.EnterY), float64(l.ExitX), float64(l.ExitY))
+		if dy >= 8 || dx >= 6 {
+			break
+		} else if i == 1000 {
+			return nil, errors.New("entrance and exit are too close")
+		}
+	}
+
+	fadeA := 0.15
+	fadeB := 0.1
+
+	// Add entrance.
+	if levelNum > 1 {
+		t := l.Tile(l.EnterX, l.EnterY)
+		t.Sprites = nil
+		t.AddSprite(SpriteFloorA)
+		t.AddSprite(SpriteBottomDoorClosedL)
+		t.AddSprite(SpriteWallLeft)
+
+		t = l.Tile(l.EnterX+1, l.EnterY)
+		t.Sprites = nil
+		t.AddSprite(SpriteFloorA)
+		t.AddSprite(SpriteBottomDoorClosedR)
+		t.AddSprite(SpriteWallRight)
+
+		// Add fading entrance hall.
+		for i := 1; i < 3; i++ {
+			colorScale := fadeA
+			if i == 2 {
+				colorScale = fadeB
+			}
+
+			t = l.Tile(l.EnterX, l.EnterY+i)
+			if t != nil {
+				t.AddSprite(SpriteFloorA)
+				t.AddSprite(SpriteWallLeft)
+				t.ForceColorScale = colorScale
+			}
+
+			t = l.Tile(l.EnterX+1, l.EnterY+i)
+			if t != nil {
+				t.AddSprite(SpriteFloorA)
+				t.AddSprite(SpriteWallRight)
+				t.ForceColorScale = colorScale
+			}
+		}
+	}
+
+	// Add exit.
+	t := l.Tile(l.ExitX, l.ExitY)
+	t.Sprites = nil
+	t.AddSprite(SpriteFloorA)
+	t.AddSprite(SpriteTopDoorClosedL)
+
+	t = l.Tile(l.ExitX+1, l.ExitY)
+	t.Sprites = nil
+	t.AddSprite(SpriteFloorA)
+	t.AddSprite(SpriteTopDoorClosedR)
+
+	// Add fading exit hall.
+	for i := 1; i < 3; i++ {
+		colorScale := fadeA
+		if i == 2 {
+			colorScale = fadeB
+		}
+
+		t = l.Tile(l.ExitX, l.ExitY-i)
+		if t != nil {
+			t.AddSprite(SpriteFloorA)
+			t.AddSprite(SpriteWallLeft)
+			t.ForceColorScale = colorScale
+		}
+
+		t = l.Tile(l.ExitX+1, l.ExitY-i)
+		if t != nil {
+			t.AddSprite(SpriteFloorA)
+			t.AddSprite(SpriteWallRight)
+			t.ForceColorScale = colorScale
+		}
+	}
+
+	// TODO make it more obvious players should enter it (arrow on first level?)
+
+	// TODO two frame sprite arrow animation
+
+	l.BakeLightmap()
+
+	return l, nil
+}
+
+// preferredWalls returns the suitable walls which are preferred, or all
+// suitable walls when none are preferred.
+func preferredWalls(suitable [][2]int, preferred [][2]int) [][2]int {
+	var walls [][2]int
+	for _, wall := range suitable {
+		for _, p := range preferred {
+			if wall == p {
+				walls = append(walls, wall)
+				break
+			}
+		}
+	}
+	if len(walls) == 0 {
+		return suitable
+	}
+	return walls
+}
+
+// Tile returns the tile at the provided coordinates, or nil.
+func (l *Level) Tile(x, y int) *Tile {
+	if x >= 0 && y >= 0 && x < l.W && y < l.H {
+		return l.Tiles[y][x]
+	}
+	return nil
+}
+
+// Size returns the size of the Level.
+func (l *Level) Size() (width, height int) {
+	return l.W, l.H
+}
+
+// IsFloor returns whether the provided position is on a floor tile.
+func (l *Level) IsFloor(x float64, y float64) bool {
+	t := l.Tile(int(math.Floor(x+.5)), int(math.Floor(y+.5)))
+	if t == nil {
+		return false
+	}
+	if !t.Floor {
+		return false
+	}
+	return true
+}
+
+// NewSpawnLocation returns a random floor position away from the players and
+// the entrance.
+func (l *Level) NewSpawnLocation() (float64, float64) {
+SPAWNLOCATION:
+	for {
+		x := float64(1 + l.rng.Intn(l.W-2))
+		y := float64(1 + l.rng.Intn(l.H-2))
+
+		if !l.IsFloor(x, y) {
+			continue
+		}
+
+		// Too close to a player.
+		playerSafeSpace := math.Min(11, l.safeSpaceLimit())
+		for _, p := range l.Players {
+			dx, dy := DeltaXY(x, y, p.X, p.Y)
+			if dx <= playerSafeSpace && dy <= playerSafeSpace {
+				continue SPAWNLOCATION
+			}
+		}
+
+		// Too close to entrance.
+		exitSafeSpace := math.Min(9, l.safeSpaceLimit())
+		dx, dy := DeltaXY(x, y, float64(l.EnterX), float64(l.EnterY))
+		if dx <= exitSafeSpace && dy <= exitSafeSpace {
+			continue
+		}
+
+		// Too close to garlic or holy water.
+		garlicSafeSpace := 2.0
+		for _, item := range l.Items {
+			if item.Health == 0 {
+				continue
+			}
+
+			dx, dy = DeltaXY(x, y, item.X, item.Y)
+			if dx <= garlicSafeSpace && dy <= garlicSafeSpace {
+				continue SPAWNLOCATION
+			}
+		}
+
+		return x, y
+	}
+
+}
+
+// safeSpaceLimit returns the greatest distance from players and the entrance
+// at which creeps may be kept when spawning. Small levels, such as those built
+// from maps, keep creeps closer.
+func (l *Level) safeSpaceLimit() float64 {
+	return float64(l.W+l.H) / 8
+}
+
+// BakeLightmap calculates the brightness of each tile.
+func (l *Level) BakeLightmap() {
+	for x := 0; x < l.W; x++ {
+		for y := 0; y < l.H; y++ {
+			t := l.Tiles[y][x]
+			v := t.ForceColorScale
+			if v == 0 {
+				for _, torch := range l.Torches {
+					if torch.Health == 0 {
+						continue
+					}
+					torchV := ColorScaleValue(float64(x), float64(y), torch.X, torch.Y)
+					v += torchV
+				}
+			}
+			t.ColorScale = v
+		}
+	}
+}
+
+// BakePartialLightmap recalculates the brightness of the tiles near a position.
+func (l *Level) BakePartialLightmap(lx, ly int) {
+	radius := 16
+	for x := lx - radius; x < lx+radius; x++ {
+		for y := ly - radius; y < ly+radius; y++ {
+			t := l.Tile(x, y)
+			if t == nil {
+				continue
+			}
+			v := 0.0
+			for _, torch := range l.Torches {
+				if torch.Health == 0 {
+					continue
+				}
+				torchV := ColorScaleValue(float64(x), float64(y), torch.X, torch.Y)
+				v += torchV
+			}
+			t.ColorScale = v
+		}
+	}
+}
+
+// AddCreep adds a new creep of the provided type to the Level.
+func (l *Level) AddCreep(creepType int) *Creep {
+	c := NewCreep(creepType, l)
+	l.addCreep(c)
+	return c
+}
+
+// addCreepAt adds a new creep of the provided type to the Level at a position.
+func (l *Level) addCreepAt(creepType int, x, y float64) *Creep {
+	c := newCreepAt(creepType, l, x, y)
+	l.addCreep(c)
+	return c
+}
+
+func (l *Level) addCreep(c *Creep) {
+	c.id = l.nextCreepID
+	l.nextCreepID++
+	l.Creeps = append(l.Creeps, c)
+	l.grid.addCreep(c)
+}
+
+// moveCreep moves a creep to a position.
+func (l *Level) moveCreep(c *Creep, x, y float64) {
+	c.X, c.Y = x, y
+	l.grid.updateCreep(c)
+}
+
+func (l *Level) addItem(item *Item) {
+	l.Items = append(l.Items, item)
+	l.grid.addItem(item)
+}
+
+// removeItem removes an item which has been used from the index of items.
+// Used items remain in the Level.
+func (l *Level) removeItem(item *Item) {
+	item.Health = 0
+	l.grid.removeItem(item)
+}
+
+// NearestPlayer returns the living player closest to a position, or nil when
+// all players are dead.
+func (l *Level) NearestPlayer(x, y float64) *Player {
+	var nearest *Player
+	var nearestDistance float64
+	for _, p := range l.Players {
+		if p.Health <= 0 {
+			continue
+		}
+		dx, dy := x-p.X, y-p.Y
+		distance := dx*dx + dy*dy
+		if nearest == nil || distance < nearestDistance {
+			nearest, nearestDistance = p, distance
+		}
+	}
+	return nearest
+}
+
+// Angle returns the angle from the second point to the first.
+func Angle(x1, y1, x2, y2 float64) float64 {
+	return math.Atan2(y1-y2, x1-x2)
+}
+
+// ColorScaleValue returns the brightness at a position lit by a light source.
+func ColorScaleValue(x, y, bx, by float64) float64 {
+	dx, dy := DeltaXY(x, y, bx, by)
+	sD := 7 / (dx + dy)
+	if sD > 1 {
+		sD = 1
+	}
+	sDB := sD
+	if dx > 4 {
+		sDB *= 0.6 / (dx / 4)
+	}
+	if dy > 4 {
+		sDB *= 0.6 / (dy / 4)
+	}
+	sD = sD * 2 * sDB
+	if sD > 1 {
+		sD = 1
+	}
+	return sD
+}
@@ -0,0 +1,104 @@
+package world
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+//go:embed levels.json
+var levelsJSON []byte
+
+// LevelDefinition holds the properties of a level. Definitions are loaded from
+// levels.json, where the position of each definition is its level number minus
+// one. The game is won once the last level is completed.
+type LevelDefinition struct {
+	Name string
+
+	Generator string // Name of the level generator, or empty for the dungeon generator
+	Rooms     int    // Rooms placed by the dungeon generator
+	Size      int    // Width and height of the level in tiles
+
+	// Name of the embedded Tiled map the level is built from instead of
+	// being generated, if any. Players enter the level through the entrance
+	// of the map, and the creeps and items of the map are spawned.
+	Map string
+
+	RequiredSouls int // Souls rescued before the exit opens
+	Garlic        int // Garlic placed when the level is generated, besides the starting garlic
+
+	Boss string // Name of the creep guarding the exit, if any
+
+	Theme string // Name of the tile set
+	Music string // Name of the music played during the level, if any
+
+	Spawn spawnConfig // Spawning of creeps at normal difficulty
+}
+
+// LevelDefinitions are the definitions of each level, in order.
+var LevelDefinitions []*LevelDefinition
+
+func init() {
+	err := json.Unmarshal(levelsJSON, &LevelDefinitions)
+	if err != nil {
+		panic(fmt.Sprintf("failed to load level definitions: %s", err))
+	}
+	if len(LevelDefinitions) == 0 {
+		panic("failed to load level definitions: no levels defined")
+	}
+	for i, def := range LevelDefinitions {
+		err = def.validate()
+		if err != nil {
+			panic(fmt.Sprintf("failed to load level definitions: %s (level %d) %s", def.Name, i+1, err))
+		}
+	}
+}
+
+// validate returns an error when the definition is invalid.
+func (def *LevelDefinition) validate() error {
+	if (def.Size <= 0 && def.Map == "") || def.RequiredSouls <= 0 {
+		return errors.New("has invalid size or required souls")
+	}
+	if def.Map != "" {
+		if _, err := loadTiledMap(def.Map); err != nil {
+			return fmt.Errorf("has invalid map %s: %s", def.Map, err)
+		}
+	}
+	if def.Boss != "" {
+		bossType, ok := creepTypeByName(def.Boss)
+		if !ok || !CreepDefinitions[bossType].Boss {
+			return fmt.Errorf("has invalid boss %s", def.Boss)
+		}
+	}
+	cfg := def.Spawn
+	if cfg.MaxCreeps <= 0 || cfg.WaveInterval <= 0 || cfg.WaveSize <= 0 || cfg.WaveGrowth <= 0 || cfg.MaxWaveSize <= 0 || cfg.GhostInterval <= 0 {
+		return errors.New("has invalid spawn configuration")
+	}
+	return nil
+}
+
+// levelDefinition returns the definition of a level, or nil if the level does
+// not exist.
+func levelDefinition(levelNum int) *LevelDefinition {
+	if levelNum < 1 || levelNum > len(LevelDefinitions) {
+		return nil
+	}
+	return LevelDefinitions[levelNum-1]
+}
+
+// LevelDefinition returns the definition of the current level, or nil once
+// the last level has been completed.
+func (w *World) LevelDefinition() *LevelDefinition {
+	return levelDefinition(w.LevelNum)
+}
+
+// creepTypeByName returns the type of the creep with the provided name.
+func creepTypeByName(name string) (int, bool) {
+	for creepType, def := range CreepDefinitions {
+		if def.Name == name {
+			return creepType, true
+		}
+	}
+	return 0, false
+}
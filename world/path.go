@@ -0,0 +1,139 @@
+package world
+
+// flowNeighbors are the offsets of the tiles adjacent to a tile.
+var flowNeighbors = [8][2]int{
+	{0, -1}, {1, 0}, {0, 1}, {-1, 0},
+	{-1, -1}, {1, -1}, {1, 1}, {-1, 1},
+}
+
+// flowField holds the distance of each tile to the nearest living player,
+// measured in steps along the floor. It is shared by all creeps in a Level and
+// is only recalculated when a player moves to a different tile.
+type flowField struct {
+	distance []int // Distance of each tile in row-major order, or -1 when unreachable
+
+	sources []int // Tiles occupied by living players
+	queue   []int
+}
+
+// tileIndex returns the index of the tile at a position. Positions are rounded
+// to the nearest tile in the same way as IsFloor.
+func (l *Level) tileIndex(x, y float64) (int, bool) {
+	tx, ty := int(x+.5), int(y+.5)
+	if x+.5 < 0 || y+.5 < 0 || tx >= l.W || ty >= l.H {
+		return 0, false
+	}
+	return ty*l.W + tx, true
+}
+
+// floorIndex returns whether the tile at an index is floor.
+func (l *Level) floorIndex(i int) bool {
+	t := l.Tiles[i/l.W][i%l.W]
+	return t != nil && t.Floor
+}
+
+// updateFlowField recalculates the flow field when any living player has moved
+// to a different tile.
+func (l *Level) updateFlowField() {
+	if l.flow == nil {
+		l.flow = &flowField{}
+	}
+	f := l.flow
+
+	var sources []int
+	for _, p := range l.Players {
+		if p.Health <= 0 {
+			continue
+		}
+		if i, ok := l.tileIndex(p.X, p.Y); ok {
+			sources = append(sources, i)
+		}
+	}
+
+	if len(f.distance) == l.W*l.H && len(sources) == len(f.sources) {
+		changed := false
+		for i := range sources {
+			if sources[i] != f.sources[i] {
+				changed = true
+				break
+			}
+		}
+		if !changed {
+			return
+		}
+	}
+	f.sources = sources
+
+	if len(f.distance) != l.W*l.H {
+		f.distance = make([]int, l.W*l.H)
+	}
+	for i := range f.distance {
+		f.distance[i] = -1
+	}
+
+	// Breadth-first search outward from every player at once, so each tile
+	// leads toward the nearest player.
+	f.queue = f.queue[:0]
+	for _, i := range sources {
+		if f.distance[i] == -1 {
+			f.distance[i] = 0
+			f.queue = append(f.queue, i)
+		}
+	}
+	for head := 0; head < len(f.queue); head++ {
+		i := f.queue[head]
+		x, y := i%l.W, i/l.W
+		for _, n := range flowNeighbors {
+			nx, ny := x+n[0], y+n[1]
+			if nx < 0 || ny < 0 || nx >= l.W || ny >= l.H {
+				continue
+			}
+			ni := ny*l.W + nx
+			if f.distance[ni] != -1 || !l.floorIndex(ni) {
+				continue
+			}
+			// Do not cut corners diagonally.
+			if n[0] != 0 && n[1] != 0 && (!l.floorIndex(y*l.W+nx) || !l.floorIndex(ny*l.W+x)) {
+				continue
+			}
+			f.distance[ni] = f.distance[i] + 1
+			f.queue = append(f.queue, ni)
+		}
+	}
+}
+
+// PathTarget returns the position to move toward from a position in order to
+// reach the nearest living player along the floor. It returns false when the
+// position is not reachable or is already on the same tile as a player.
+func (l *Level) PathTarget(x, y float64) (float64, float64, bool) {
+	if l.flow == nil || len(l.flow.distance) != l.W*l.H {
+		return 0, 0, false
+	}
+	f := l.flow
+
+	i, ok := l.tileIndex(x, y)
+	if !ok || f.distance[i] <= 0 {
+		return 0, 0, false
+	}
+
+	tx, ty := i%l.W, i/l.W
+	best, bestDistance := -1, f.distance[i]
+	for _, n := range flowNeighbors {
+		nx, ny := tx+n[0], ty+n[1]
+		if nx < 0 || ny < 0 || nx >= l.W || ny >= l.H {
+			continue
+		}
+		ni := ny*l.W + nx
+		if d := f.distance[ni]; d != -1 && d < bestDistance {
+			// Do not cut corners diagonally.
+			if n[0] != 0 && n[1] != 0 && (!l.floorIndex(ty*l.W+nx) || !l.floorIndex(ny*l.W+tx)) {
+				continue
+			}
+			best, bestDistance = ni, d
+		}
+	}
+	if best == -1 {
+		return 0, 0, false
+	}
+	return float64(best % l.W), float64(best / l.W), true
+}
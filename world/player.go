@@ -0,0 +1,42 @@
+package world
+
+import (
+	"time"
+)
+
+// StartingHealth is the amount of health the player starts with.
+const StartingHealth = 3
+
+// Player represents the player.
+type Player struct {
+	X, Y float64
+
+	Angle float64
+
+	Weapon *Weapon
+
+	HasTorch bool
+
+	Score int
+
+	Health int
+
+	GarlicUntil    time.Time
+	HolyWaterUntil time.Time
+}
+
+// NewPlayer returns a new Player.
+func NewPlayer() (*Player, error) {
+	p := &Player{
+		Weapon:   NewUzi(),
+		HasTorch: true,
+		Health:   StartingHealth,
+	}
+	return p, nil
+}
+
+// repels returns whether the player is protected from creeps by garlic or holy
+// water.
+func (p *Player) repels() bool {
+	return !p.GarlicUntil.IsZero() || !p.HolyWaterUntil.IsZero()
+}
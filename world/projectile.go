@@ -0,0 +1,25 @@
+package world
+
+import (
+	"image/color"
+)
+
+// Factions of projectiles. Projectiles only hit members of other factions.
+const (
+	FactionPlayers = iota
+	FactionCreeps
+)
+
+// Projectile represents a projectile, such as a bullet.
+type Projectile struct {
+	X, Y       float64
+	Angle      float64
+	Speed      float64
+	Color      color.Color
+	ColorScale float64
+
+	Faction int
+
+	Player *Player // Player which fired the projectile, if any
+	Creep  *Creep  // Creep which fired the projectile, if any
+}
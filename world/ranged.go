@@ -0,0 +1,77 @@
+package world
+
+import (
+	"math"
+
+	"golang.org/x/image/colornames"
+)
+
+// rangedAttack holds the properties of the projectiles fired by a type of
+// creep, which are part of its definition.
+type rangedAttack struct {
+	Range    float64 // Distance at which players are fired at
+	Distance float64 // Distance kept from players
+	Cooldown int     // Ticks between shots
+	Speed    float64 // Distance travelled by projectiles each tick
+
+	Sound  string // Name of the sound played when firing
+	Volume float64
+}
+
+// keepDistance moves a ranged creep to keep its distance from its player,
+// approaching them while they are out of range.
+func (c *Creep) keepDistance() {
+	r := c.Definition().Ranged
+	p := c.player
+
+	dx, dy := p.X-c.X, p.Y-c.Y
+	distance := math.Sqrt(dx*dx + dy*dy)
+	if distance > r.Range {
+		c.seekPlayer()
+		return
+	}
+
+	c.seeking = false
+	if distance >= r.Distance {
+		c.moveX, c.moveY = 0, 0
+		return
+	}
+
+	// Back away.
+	a := Angle(c.X, c.Y, p.X, p.Y)
+	speed := c.moveSpeed() / 9
+	c.moveX, c.moveY = math.Cos(a)*speed, math.Sin(a)*speed
+}
+
+// creepFire fires a projectile from a ranged creep at its player while it is
+// hunting them within range.
+func (w *World) creepFire(c *Creep) {
+	if c.reload > 0 {
+		c.reload--
+		return
+	}
+
+	r := c.Definition().Ranged
+	p := c.player
+	if p == nil || p.Health <= 0 || c.Awareness != AwarenessHunting || c.Staggered() || c.repelled() {
+		return
+	}
+	dx, dy := p.X-c.X, p.Y-c.Y
+	if dx*dx+dy*dy > r.Range*r.Range || !c.sees(p) {
+		return
+	}
+	c.reload = r.Cooldown
+
+	w.Projectiles = append(w.Projectiles, &Projectile{
+		X:          c.X,
+		Y:          c.Y,
+		Angle:      Angle(p.X, p.Y, c.X, c.Y),
+		Speed:      r.Speed,
+		Color:      colornames.Darkred,
+		ColorScale: 1.0,
+		Faction:    FactionCreeps,
+		Creep:      c,
+	})
+
+	w.addEvent(Event{EventType: EventCreepFire, X: c.X, Y: c.Y, Creep: c})
+}
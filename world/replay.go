@@ -0,0 +1,227 @@
+package world
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	replayMagic   = "CARP"
+	replayVersion = 4
+)
+
+const (
+	replayButtonFire = 1 << iota
+)
+
+const (
+	replayFlagGodMode = 1 << iota
+	replayFlagNoclipMode
+)
+
+type replayHeader struct {
+	Magic   [4]byte
+	Version uint16
+	Seed    int64
+	Level   int16
+	Flags   uint8
+}
+
+type replayFrame struct {
+	MoveX, MoveY int8
+	Angle        int16
+	Buttons      uint8
+	Cheat        uint8
+}
+
+// Replay is a recording of the input of the players during every tick of a
+// game. Playing a Replay back using the same seed reproduces the game exactly.
+type Replay struct {
+	Seed  int64
+	Level int // Level warped to at the start of the game, if any
+
+	GodMode    bool
+	NoclipMode bool
+
+	Difficulty int
+	Generator  string
+
+	Inputs [][]Input // Input of each player during each tick
+}
+
+// NewReplay returns a new Replay of the current game of the World.
+func NewReplay(w *World) *Replay {
+	r := &Replay{
+		Seed:       w.Seed,
+		GodMode:    w.GodMode,
+		NoclipMode: w.NoclipMode,
+		Difficulty: w.Difficulty,
+		Generator:  w.Generator,
+	}
+	if w.LevelNum > 1 {
+		r.Level = w.LevelNum
+	}
+	return r
+}
+
+// Record adds the input of each player during a single tick to the Replay.
+// The input must already be quantized.
+func (r *Replay) Record(inputs []Input) {
+	r.Inputs = append(r.Inputs, append([]Input(nil), inputs...))
+}
+
+// Write writes the Replay in a compressed binary format.
+func (r *Replay) Write(w io.Writer) error {
+	zw := gzip.NewWriter(w)
+	bw := bufio.NewWriter(zw)
+
+	h := replayHeader{
+		Version: replayVersion,
+		Seed:    r.Seed,
+		Level:   int16(r.Level),
+	}
+	copy(h.Magic[:], replayMagic)
+	if r.GodMode {
+		h.Flags |= replayFlagGodMode
+	}
+	if r.NoclipMode {
+		h.Flags |= replayFlagNoclipMode
+	}
+	err := binary.Write(bw, binary.LittleEndian, &h)
+	if err != nil {
+		return err
+	}
+	err = bw.WriteByte(uint8(r.Difficulty))
+	if err != nil {
+		return err
+	}
+	generator := -1
+	for i, name := range LevelGenerators {
+		if r.Generator == name || (r.Generator == "" && name == GeneratorDungeon) {
+			generator = i
+		}
+	}
+	if generator == -1 {
+		return fmt.Errorf("unknown level generator %s", r.Generator)
+	}
+	err = bw.WriteByte(uint8(generator))
+	if err != nil {
+		return err
+	}
+
+	for _, inputs := range r.Inputs {
+		err = bw.WriteByte(uint8(len(inputs)))
+		if err != nil {
+			return err
+		}
+		for _, in := range inputs {
+			f := replayFrame{
+				MoveX: quantizeMove(in.MoveX),
+				MoveY: quantizeMove(in.MoveY),
+				Angle: quantizeAngle(in.Angle),
+				Cheat: uint8(in.Cheat),
+			}
+			if in.Fire {
+				f.Buttons |= replayButtonFire
+			}
+			err = binary.Write(bw, binary.LittleEndian, &f)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	err = bw.Flush()
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// ReadReplay reads a Replay written by Replay.Write. Replays recorded before
+// multiple players, difficulties or level generators were supported are also read.
+func ReadReplay(r io.Reader) (*Replay, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replay: %s", err)
+	}
+	defer zr.Close()
+	br := bufio.NewReader(zr)
+
+	var h replayHeader
+	err = binary.Read(br, binary.LittleEndian, &h)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replay header: %s", err)
+	} else if string(h.Magic[:]) != replayMagic {
+		return nil, errors.New("failed to read replay: invalid file")
+	} else if h.Version < 1 || h.Version > replayVersion {
+		return nil, fmt.Errorf("failed to read replay: unsupported version %d", h.Version)
+	}
+
+	replay := &Replay{
+		Seed:       h.Seed,
+		Level:      int(h.Level),
+		GodMode:    h.Flags&replayFlagGodMode != 0,
+		NoclipMode: h.Flags&replayFlagNoclipMode != 0,
+		Difficulty: DifficultyNormal,
+	}
+
+	// Version 3 replays record the difficulty after the header.
+	if h.Version > 2 {
+		b, err := br.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read replay header: %s", err)
+		}
+		replay.Difficulty = int(b)
+	}
+
+	// Version 4 replays record the level generator after the difficulty.
+	if h.Version > 3 {
+		b, err := br.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read replay header: %s", err)
+		} else if int(b) >= len(LevelGenerators) {
+			return nil, fmt.Errorf("failed to read replay: unknown level generator %d", b)
+		}
+		replay.Generator = LevelGenerators[b]
+	}
+
+	var f replayFrame
+	for {
+		// Version 1 replays contain the input of a single player.
+		players := 1
+		if h.Version > 1 {
+			b, err := br.ReadByte()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("failed to read replay: %s", err)
+			}
+			players = int(b)
+		}
+
+		inputs := make([]Input, players)
+		for i := range inputs {
+			err = binary.Read(br, binary.LittleEndian, &f)
+			if err == io.EOF && h.Version == 1 {
+				return replay, nil
+			} else if err != nil {
+				return nil, fmt.Errorf("failed to read replay: %s", err)
+			}
+
+			inputs[i] = Input{
+				MoveX: float64(f.MoveX) / moveScale,
+				MoveY: float64(f.MoveY) / moveScale,
+				Angle: float64(f.Angle) / angleScale,
+				Fire:  f.Buttons&replayButtonFire != 0,
+				Cheat: int(f.Cheat),
+			}
+		}
+		replay.Inputs = append(replay.Inputs, inputs)
+	}
+	return replay, nil
+}
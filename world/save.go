@@ -0,0 +1,484 @@
+package world
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"io"
+	"math/rand"
+	"time"
+)
+
+// saveVersion is the version of the save file format. It must be incremented
+// whenever the format changes.
+const saveVersion = 14
+
+type saveFile struct {
+	Version int
+
+	Seed      int64
+	RandState uint64
+	Tick      int
+
+	LevelNum int
+
+	GodMode    bool
+	NoclipMode bool
+
+	Difficulty int
+	Generator  string
+	Director   director
+
+	LastCreepSound time.Time
+
+	Players      []*Player
+	SoulsRescued int
+	SoulsEaten   int
+
+	Completed []LevelStats // Statistics of each completed level
+
+	Level *saveLevel
+
+	Projectiles []*saveProjectile
+}
+
+type saveLevel struct {
+	Num int
+
+	W, H     int
+	TileSize int
+
+	Tiles []*saveTile // Tiles in row-major order
+
+	Items  []*saveItem
+	Creeps []*saveCreep
+
+	NextCreepID int
+
+	EnterX, EnterY int
+	ExitX, ExitY   int
+
+	ExitOpenTime time.Time
+
+	RequiredSouls int
+}
+
+type saveTile struct {
+	Tile
+
+	TopWall   bool `json:",omitempty"`
+	SideWall  bool `json:",omitempty"`
+	OtherWall bool `json:",omitempty"`
+}
+
+type saveItem struct {
+	X, Y     float64
+	ItemType int
+	Health   int
+}
+
+type saveCreep struct {
+	ID int
+
+	X, Y float64
+
+	Frame     int
+	Frames    int
+	LastFrame time.Time
+
+	CreepType int
+
+	MoveX, MoveY float64
+
+	Tick       int
+	NextAction int
+	Seeking    bool
+
+	Leader    int // ID of the swarm leader, or -1
+	Followers int
+
+	Boss *bossState
+
+	Awareness              int
+	LastKnownX, LastKnownY float64
+	AwareTicks             int
+
+	LastHit        time.Time
+	Stagger        int
+	KnockX, KnockY float64
+
+	Reload int
+
+	SoulsEaten int
+	Prey       int // ID of the soul sought by the creep, or -1
+
+	Health int
+
+	Angle   float64
+	Flipped bool
+	State   int
+
+	Player int // Index of the targeted player, or -1
+}
+
+type saveProjectile struct {
+	X, Y       float64
+	Angle      float64
+	Speed      float64
+	Color      *color.RGBA
+	ColorScale float64
+
+	Faction int
+
+	Player int // Index of the player which fired the projectile, or -1
+	Creep  int // ID of the creep which fired the projectile, or -1
+}
+
+// playerIndex returns the index of a player, or -1 when the player is nil or
+// not in the game.
+func (w *World) playerIndex(p *Player) int {
+	for i, player := range w.Players {
+		if player == p {
+			return i
+		}
+	}
+	return -1
+}
+
+// Save writes the current state of the World in a compressed format. The game
+// continues exactly as it would have without saving once it is loaded.
+func (w *World) Save(wr io.Writer) error {
+	l := w.Level
+
+	s := &saveFile{
+		Version:        saveVersion,
+		Seed:           w.Seed,
+		RandState:      w.src.state,
+		Tick:           w.Clock.tick,
+		LevelNum:       w.LevelNum,
+		GodMode:        w.GodMode,
+		NoclipMode:     w.NoclipMode,
+		Difficulty:     w.Difficulty,
+		Generator:      w.Generator,
+		Director:       w.director,
+		LastCreepSound: w.lastCreepSound,
+		Players:        w.Players,
+		SoulsRescued:   w.SoulsRescued,
+		SoulsEaten:     w.SoulsEaten,
+		Completed:      w.CompletedLevels,
+		Level: &saveLevel{
+			Num:           l.Num,
+			W:             l.W,
+			H:             l.H,
+			TileSize:      l.TileSize,
+			Tiles:         make([]*saveTile, 0, l.W*l.H),
+			EnterX:        l.EnterX,
+			EnterY:        l.EnterY,
+			ExitX:         l.ExitX,
+			ExitY:         l.ExitY,
+			ExitOpenTime:  l.ExitOpenTime,
+			RequiredSouls: l.RequiredSouls,
+			NextCreepID:   l.nextCreepID,
+		},
+	}
+
+	for y := 0; y < l.H; y++ {
+		for x := 0; x < l.W; x++ {
+			s.Level.Tiles = append(s.Level.Tiles, &saveTile{
+				Tile:      *l.Tiles[y][x],
+				TopWall:   l.TopWalls[y][x] != nil,
+				SideWall:  l.SideWalls[y][x] != nil,
+				OtherWall: l.OtherWalls[y][x] != nil,
+			})
+		}
+	}
+
+	for _, item := range l.Items {
+		s.Level.Items = append(s.Level.Items, &saveItem{
+			X:        item.X,
+			Y:        item.Y,
+			ItemType: item.ItemType,
+			Health:   item.Health,
+		})
+	}
+
+	for _, c := range l.Creeps {
+		c.Lock()
+		leaderID := -1
+		if c.following() {
+			leaderID = c.leader.id
+		}
+		preyID := -1
+		if c.prey != nil {
+			preyID = c.prey.id
+		}
+		s.Level.Creeps = append(s.Level.Creeps, &saveCreep{
+			ID:         c.id,
+			X:          c.X,
+			Y:          c.Y,
+			Frame:      c.Frame,
+			Frames:     c.Frames,
+			LastFrame:  c.LastFrame,
+			CreepType:  c.CreepType,
+			MoveX:      c.moveX,
+			MoveY:      c.moveY,
+			Tick:       c.tick,
+			NextAction: c.nextAction,
+			Seeking:    c.seeking,
+			Leader:     leaderID,
+			Followers:  c.Followers,
+			Boss:       c.boss,
+			Awareness:  c.Awareness,
+			LastKnownX: c.lastKnownX,
+			LastKnownY: c.lastKnownY,
+			AwareTicks: c.awareTicks,
+			LastHit:    c.LastHit,
+			Stagger:    c.stagger,
+			KnockX:     c.knockX,
+			KnockY:     c.knockY,
+			Reload:     c.reload,
+			SoulsEaten: c.SoulsEaten,
+			Prey:       preyID,
+			Health:     c.Health,
+			Angle:      c.Angle,
+			Flipped:    c.Flipped,
+			State:      c.State,
+			Player:     w.playerIndex(c.player),
+		})
+		c.Unlock()
+	}
+
+	for _, p := range w.Projectiles {
+		sp := &saveProjectile{
+			X:          p.X,
+			Y:          p.Y,
+			Angle:      p.Angle,
+			Speed:      p.Speed,
+			ColorScale: p.ColorScale,
+			Faction:    p.Faction,
+			Player:     w.playerIndex(p.Player),
+			Creep:      -1,
+		}
+		if p.Creep != nil {
+			sp.Creep = p.Creep.id
+		}
+		if p.Color != nil {
+			c := color.RGBAModel.Convert(p.Color).(color.RGBA)
+			sp.Color = &c
+		}
+		s.Projectiles = append(s.Projectiles, sp)
+	}
+
+	zw := gzip.NewWriter(wr)
+	err := json.NewEncoder(zw).Encode(s)
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// Load restores a state of the World written by Save. The World is left
+// unchanged when an error is returned.
+func (w *World) Load(r io.Reader) error {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("failed to read save file: %s", err)
+	}
+	defer zr.Close()
+
+	s := &saveFile{}
+	err = json.NewDecoder(zr).Decode(s)
+	if err != nil {
+		return fmt.Errorf("failed to read save file: %s", err)
+	} else if s.Version != saveVersion {
+		return fmt.Errorf("failed to read save file: unsupported version %d", s.Version)
+	} else if len(s.Players) == 0 || s.Level == nil || s.Level.W <= 0 || s.Level.H <= 0 || len(s.Level.Tiles) != s.Level.W*s.Level.H {
+		return fmt.Errorf("failed to read save file: invalid file")
+	}
+	for _, p := range s.Players {
+		if p == nil || p.Weapon == nil {
+			return fmt.Errorf("failed to read save file: invalid file")
+		}
+	}
+
+	// player returns the loaded player at an index, or nil.
+	player := func(i int) *Player {
+		if i < 0 || i >= len(s.Players) {
+			return nil
+		}
+		return s.Players[i]
+	}
+
+	// Every creep has a type and targets a player.
+	for _, sc := range s.Level.Creeps {
+		if sc == nil || sc.CreepType < 0 || sc.CreepType >= len(CreepDefinitions) || player(sc.Player) == nil {
+			return fmt.Errorf("failed to read save file: invalid file")
+		}
+	}
+
+	src := &source{state: s.RandState}
+	rng := rand.New(src)
+
+	l := &Level{
+		Num:           s.Level.Num,
+		W:             s.Level.W,
+		H:             s.Level.H,
+		TileSize:      s.Level.TileSize,
+		Players:       s.Players,
+		rng:           rng,
+		clock:         w.Clock,
+		EnterX:        s.Level.EnterX,
+		EnterY:        s.Level.EnterY,
+		ExitX:         s.Level.ExitX,
+		ExitY:         s.Level.ExitY,
+		ExitOpenTime:  s.Level.ExitOpenTime,
+		RequiredSouls: s.Level.RequiredSouls,
+		nextCreepID:   s.Level.NextCreepID,
+	}
+	l.grid = newSpatialHash(l.W, l.H)
+
+	l.Tiles = make([][]*Tile, l.H)
+	l.TopWalls = make([][]*Tile, l.H)
+	l.SideWalls = make([][]*Tile, l.H)
+	l.OtherWalls = make([][]*Tile, l.H)
+	for y := 0; y < l.H; y++ {
+		l.Tiles[y] = make([]*Tile, l.W)
+		l.TopWalls[y] = make([]*Tile, l.W)
+		l.SideWalls[y] = make([]*Tile, l.W)
+		l.OtherWalls[y] = make([]*Tile, l.W)
+		for x := 0; x < l.W; x++ {
+			st := s.Level.Tiles[y*l.W+x]
+			t := &Tile{}
+			if st != nil {
+				*t = st.Tile
+				if st.TopWall {
+					l.TopWalls[y][x] = t
+				}
+				if st.SideWall {
+					l.SideWalls[y][x] = t
+				}
+				if st.OtherWall {
+					l.OtherWalls[y][x] = t
+				}
+			}
+			l.Tiles[y][x] = t
+		}
+	}
+
+	for _, si := range s.Level.Items {
+		item := &Item{
+			X:        si.X,
+			Y:        si.Y,
+			ItemType: si.ItemType,
+			level:    l,
+			Health:   si.Health,
+		}
+		l.Items = append(l.Items, item)
+		if item.Health > 0 {
+			l.grid.addItem(item)
+		}
+	}
+
+	for _, sc := range s.Level.Creeps {
+		c := &Creep{
+			id:         sc.ID,
+			X:          sc.X,
+			Y:          sc.Y,
+			Frame:      sc.Frame,
+			Frames:     sc.Frames,
+			LastFrame:  sc.LastFrame,
+			CreepType:  sc.CreepType,
+			moveX:      sc.MoveX,
+			moveY:      sc.MoveY,
+			tick:       sc.Tick,
+			nextAction: sc.NextAction,
+			seeking:    sc.Seeking,
+			Followers:  sc.Followers,
+			boss:       sc.Boss,
+			Awareness:  sc.Awareness,
+			lastKnownX: sc.LastKnownX,
+			lastKnownY: sc.LastKnownY,
+			awareTicks: sc.AwareTicks,
+			LastHit:    sc.LastHit,
+			stagger:    sc.Stagger,
+			knockX:     sc.KnockX,
+			knockY:     sc.KnockY,
+			reload:     sc.Reload,
+			SoulsEaten: sc.SoulsEaten,
+			level:      l,
+			player:     player(sc.Player),
+			rng:        rng,
+			Health:     sc.Health,
+			Angle:      sc.Angle,
+			Flipped:    sc.Flipped,
+			State:      sc.State,
+		}
+		l.Creeps = append(l.Creeps, c)
+		l.grid.addCreep(c)
+		if c.CreepType == TypeTorch {
+			l.Torches = append(l.Torches, c)
+		}
+		if c.boss != nil {
+			l.Boss = c
+		}
+		if c.Health > 0 {
+			l.LiveCreeps++
+		}
+	}
+
+	// Restore swarms and prey after all creeps have been loaded.
+	creepIDs := make(map[int]*Creep, len(l.Creeps))
+	for _, c := range l.Creeps {
+		creepIDs[c.id] = c
+	}
+	for i, sc := range s.Level.Creeps {
+		if sc.Leader != -1 {
+			l.Creeps[i].leader = creepIDs[sc.Leader]
+		}
+		if sc.Prey != -1 {
+			l.Creeps[i].prey = creepIDs[sc.Prey]
+		}
+	}
+
+	var projectiles []*Projectile
+	for _, sp := range s.Projectiles {
+		p := &Projectile{
+			X:          sp.X,
+			Y:          sp.Y,
+			Angle:      sp.Angle,
+			Speed:      sp.Speed,
+			ColorScale: sp.ColorScale,
+			Faction:    sp.Faction,
+			Player:     player(sp.Player),
+		}
+		if sp.Creep != -1 {
+			p.Creep = creepIDs[sp.Creep]
+		}
+		if sp.Color != nil {
+			p.Color = *sp.Color
+		}
+		projectiles = append(projectiles, p)
+	}
+
+	w.Seed = s.Seed
+	w.src = src
+	w.rng = rng
+	w.Clock.Reset()
+	w.Clock.tick = s.Tick
+	w.LevelNum = s.LevelNum
+	w.Level = l
+	w.Projectiles = projectiles
+	w.GodMode = s.GodMode
+	w.NoclipMode = s.NoclipMode
+	w.Difficulty = s.Difficulty
+	w.Generator = s.Generator
+	w.director = s.Director
+	w.Events = nil
+	w.lastCreepSound = s.LastCreepSound
+	w.Players = s.Players
+	w.SoulsRescued = s.SoulsRescued
+	w.SoulsEaten = s.SoulsEaten
+	w.CompletedLevels = s.Completed
+	return nil
+}
@@ -0,0 +1,106 @@
+package world
+
+// Simulation levels of creeps, which depend on their distance from players.
+const (
+	// SimulationFull creeps are updated every tick.
+	SimulationFull = iota
+
+	// SimulationFar creeps are updated every farUpdateTicks ticks, and do not
+	// flock, bite, fire or avoid garlic.
+	SimulationFar
+
+	// SimulationAsleep creeps are not updated until a player approaches them
+	// or they hear a noise. Waves spawned by the spawn director arrive within
+	// the simulation radius and are never asleep.
+	SimulationAsleep
+)
+
+const (
+	// DefaultSimulationRadius is the distance from players within which
+	// creeps are fully simulated, beyond the edges of the screen at the
+	// default zoom level.
+	DefaultSimulationRadius = 32
+
+	// DefaultSleepRadius is the distance from players beyond which unaware
+	// creeps sleep.
+	DefaultSleepRadius = 64
+
+	// farUpdateTicks is the number of ticks between updates of far creeps.
+	farUpdateTicks = 8
+)
+
+// simulationLevel returns the simulation level of a living creep. Bosses,
+// ghosts, static and staggered creeps are always fully simulated.
+func (w *World) simulationLevel(c *Creep) int {
+	if c.boss != nil || c.CreepType == TypeGhost || c.Definition().Static || c.Staggered() {
+		return SimulationFull
+	}
+
+	distance := -1.0
+	for _, p := range w.Players {
+		if p.Health <= 0 {
+			continue
+		}
+		dx, dy := DeltaXY(c.X, c.Y, p.X, p.Y)
+		if dy > dx {
+			dx = dy
+		}
+		if distance < 0 || dx < distance {
+			distance = dx
+		}
+	}
+
+	switch {
+	case distance <= w.SimulationRadius:
+		return SimulationFull
+	case distance <= w.SleepRadius || c.Awareness != AwarenessUnaware || c.seeking:
+		return SimulationFar
+	default:
+		return SimulationAsleep
+	}
+}
+
+// updateFar updates a creep far from all players. The creep moves as it would
+// during the provided number of ticks, without flocking or avoiding garlic.
+func (c *Creep) updateFar(ticks int) {
+	c.Lock()
+	defer c.Unlock()
+
+	p := c.level.NearestPlayer(c.X, c.Y)
+	if p == nil {
+		return
+	}
+	c.player = p
+
+	c.tick += ticks
+
+	if c.CreepType == TypeBat && !c.updateBat() {
+		return
+	}
+
+	if c.Awareness != AwarenessUnaware {
+		// Awareness is updated a single tick at a time.
+		c.awareTicks += ticks - 1
+	}
+
+	if c.Definition().Collectable {
+		if c.tick >= c.nextAction {
+			c.doNextAction()
+		} else if c.seeking {
+			c.steer()
+		}
+	} else if c.updateAwareness(c.repelled()) {
+		// Investigating.
+	} else if c.tick >= c.nextAction {
+		if c.following() {
+			c.followLeader()
+		} else {
+			c.doNextAction()
+		}
+		c.tick = 0
+	} else if c.seeking {
+		c.steer()
+	}
+
+	c.move(c.X+c.moveX*float64(ticks), c.Y+c.moveY*float64(ticks))
+}
@@ -0,0 +1,27 @@
+package world
+
+// source is a random source whose state may be saved and restored, which
+// allows a saved game to continue exactly as it would have without saving.
+// It implements the SplitMix64 algorithm.
+type source struct {
+	state uint64
+}
+
+// Seed sets the state of the source.
+func (s *source) Seed(seed int64) {
+	s.state = uint64(seed)
+}
+
+// Uint64 returns a pseudo-random 64-bit value.
+func (s *source) Uint64() uint64 {
+	s.state += 0x9e3779b97f4a7c15
+	z := s.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// Int63 returns a non-negative pseudo-random 63-bit integer.
+func (s *source) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
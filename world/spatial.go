@@ -0,0 +1,149 @@
+package world
+
+import (
+	"math"
+)
+
+// spatialCellSize is the width and height of each cell of a spatialHash in
+// tiles.
+const spatialCellSize = 4
+
+// spatialHash is a uniform grid which indexes the creeps and items of a Level
+// by position, allowing nearby entities to be found without checking every
+// entity in the Level. Creeps within each cell are kept in the order they
+// were added to the Level, so query results are deterministic.
+type spatialHash struct {
+	w, h int // Size in cells
+
+	creeps [][]*Creep
+	items  [][]*Item
+}
+
+func newSpatialHash(levelW, levelH int) *spatialHash {
+	w, h := levelW/spatialCellSize+1, levelH/spatialCellSize+1
+	return &spatialHash{
+		w:      w,
+		h:      h,
+		creeps: make([][]*Creep, w*h),
+		items:  make([][]*Item, w*h),
+	}
+}
+
+// cell returns the coordinates of the cell containing a position. Positions
+// outside of the grid are placed in the nearest cell.
+func (s *spatialHash) cell(x, y float64) (int, int) {
+	cx, cy := int(math.Floor(x/spatialCellSize)), int(math.Floor(y/spatialCellSize))
+	if cx < 0 {
+		cx = 0
+	} else if cx >= s.w {
+		cx = s.w - 1
+	}
+	if cy < 0 {
+		cy = 0
+	} else if cy >= s.h {
+		cy = s.h - 1
+	}
+	return cx, cy
+}
+
+func (s *spatialHash) cellIndex(x, y float64) int {
+	cx, cy := s.cell(x, y)
+	return cy*s.w + cx
+}
+
+func (s *spatialHash) addCreep(c *Creep) {
+	i := s.cellIndex(c.X, c.Y)
+	c.cell = i
+
+	// Keep creeps sorted by the order they were added to the Level.
+	cell := append(s.creeps[i], nil)
+	j := len(cell) - 1
+	for j > 0 && cell[j-1].id > c.id {
+		cell[j] = cell[j-1]
+		j--
+	}
+	cell[j] = c
+	s.creeps[i] = cell
+}
+
+func (s *spatialHash) removeCreep(c *Creep) {
+	cell := s.creeps[c.cell]
+	for j, other := range cell {
+		if other == c {
+			s.creeps[c.cell] = append(cell[:j], cell[j+1:]...)
+			break
+		}
+	}
+	c.cell = -1
+}
+
+// updateCreep moves a creep to the cell containing its current position.
+func (s *spatialHash) updateCreep(c *Creep) {
+	if c.cell == -1 || s.cellIndex(c.X, c.Y) == c.cell {
+		return
+	}
+	s.removeCreep(c)
+	s.addCreep(c)
+}
+
+func (s *spatialHash) addItem(item *Item) {
+	i := s.cellIndex(item.X, item.Y)
+	s.items[i] = append(s.items[i], item)
+}
+
+func (s *spatialHash) removeItem(item *Item) {
+	i := s.cellIndex(item.X, item.Y)
+	cell := s.items[i]
+	for j, other := range cell {
+		if other == item {
+			s.items[i] = append(cell[:j], cell[j+1:]...)
+			return
+		}
+	}
+}
+
+// cellCreeps returns the creeps within a cell.
+func (s *spatialHash) cellCreeps(cx, cy int) []*Creep {
+	if cx < 0 || cy < 0 || cx >= s.w || cy >= s.h {
+		return nil
+	}
+	return s.creeps[cy*s.w+cx]
+}
+
+// queryCreeps appends the creeps within the cells overlapping the square
+// around a position to buf and returns it. Creeps are returned in the order
+// they were added to the Level. The distance to each creep must still be
+// checked by the caller.
+func (s *spatialHash) queryCreeps(x, y, radius float64, buf []*Creep) []*Creep {
+	buf = buf[:0]
+	minX, minY := s.cell(x-radius, y-radius)
+	maxX, maxY := s.cell(x+radius, y+radius)
+	for cy := minY; cy <= maxY; cy++ {
+		for cx := minX; cx <= maxX; cx++ {
+			buf = append(buf, s.creeps[cy*s.w+cx]...)
+		}
+	}
+
+	// Merge the sorted cells.
+	for i := 1; i < len(buf); i++ {
+		for j := i; j > 0 && buf[j-1].id > buf[j].id; j-- {
+			buf[j-1], buf[j] = buf[j], buf[j-1]
+		}
+	}
+	return buf
+}
+
+// queryItems appends the items within the cells overlapping the square around
+// a position to buf and returns it. The distance to each item must still be
+// checked by the caller.
+func (s *spatialHash) queryItems(x, y, radius float64, buf []*Item) []*Item {
+	buf = buf[:0]
+	minX, minY := s.cell(x-radius, y-radius)
+	maxX, maxY := s.cell(x+radius, y+radius)
+	for cy := minY; cy <= maxY; cy++ {
+		for cx := minX; cx <= maxX; cx++ {
+			buf = append(buf, s.items[cy*s.w+cx]...)
+		}
+	}
+	return buf
+}
@@ -0,0 +1,58 @@
+package world
+
+// SpriteID identifies a tile sprite. Sprites are resolved to images when the
+// world is drawn, so the simulation never depends on any graphics.
+type SpriteID int
+
+// Sandstone dungeon sprites.
+const (
+	SpriteNone SpriteID = iota
+	SpriteFloorA
+	SpriteFloorB
+	SpriteFloorC
+	SpriteWallTop
+	SpriteWallBottom
+	SpriteWallBottomLeft
+	SpriteWallBottomRight
+	SpriteWallLeft
+	SpriteWallRight
+	SpriteWallTopLeft
+	SpriteWallTopRight
+	SpriteWallPillar
+	SpriteTopDoorClosedL
+	SpriteTopDoorClosedR
+	SpriteTopDoorOpenTL
+	SpriteTopDoorOpenTR
+	SpriteTopDoorOpenBL
+	SpriteTopDoorOpenBR
+	SpriteBottomDoorClosedL
+	SpriteBottomDoorClosedR
+	SpriteBottomDoorOpenTL
+	SpriteBottomDoorOpenTR
+	SpriteBottomDoorOpenBL
+	SpriteBottomDoorOpenBR
+)
+
+// Ojas dungeon sprites.
+const (
+	SpriteGrass11 SpriteID = iota + 100
+	SpriteGrass12
+	SpriteGrass13
+	SpriteGrass14
+	SpriteGrass15
+	SpriteGrass16
+	SpriteGrass21
+	SpriteGrass31
+	SpriteGrass41
+	SpriteGrass42
+	SpriteGrass51
+	SpriteGrass61
+	SpriteGrass71
+	SpriteGrass81
+	SpriteGrass82
+	SpriteGrass91
+	SpriteOjasWall1
+	SpriteOjasVent1
+	SpriteOjasDoor11
+	SpriteOjasDoor12
+)
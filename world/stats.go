@@ -0,0 +1,26 @@
+package world
+
+import (
+	"fmt"
+)
+
+// LevelStats are the statistics of a completed level.
+type LevelStats struct {
+	Level int
+
+	SoulsRescued int
+	SoulsEaten   int // Souls eaten by creeps before they were rescued
+}
+
+// completeLevel records the statistics of the current level as it is
+// completed.
+func (w *World) completeLevel() {
+	stats := LevelStats{
+		Level:        w.LevelNum,
+		SoulsRescued: w.SoulsRescued,
+		SoulsEaten:   w.SoulsEaten,
+	}
+	w.CompletedLevels = append(w.CompletedLevels, stats)
+
+	w.addEvent(Event{EventType: EventLevelComplete, Message: fmt.Sprintf("LEVEL %d COMPLETE - %d SOULS RESCUED, %d EATEN", stats.Level, stats.SoulsRescued, stats.SoulsEaten)})
+}
@@ -0,0 +1,22 @@
+package world
+
+// Tile represents a space with an x,y coordinate within a Level. Any number of
+// sprites may be added to a Tile.
+type Tile struct {
+	Sprites         []SpriteID
+	Blood           []int64 // Blood splatter seeds
+	Floor           bool
+	Wall            bool
+	ColorScale      float64 // Minimum color scale (brightness)
+	ForceColorScale float64 // Override lightmap value
+}
+
+// AddSprite adds a sprite to the Tile.
+func (t *Tile) AddSprite(s SpriteID) {
+	t.Sprites = append(t.Sprites, s)
+}
+
+// ClearSprites removes all sprites from the Tile.
+func (t *Tile) ClearSprites() {
+	t.Sprites = t.Sprites[:0]
+}
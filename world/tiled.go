@@ -0,0 +1,546 @@
+package world
+
+import (
+	"embed"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"path"
+	"strconv"
+	"strings"
+)
+
+//go:embed maps
+var mapsFS embed.FS
+
+// Names of tile layers of Tiled maps which set the floor and wall flags of
+// tiles. Tiles of any other tile layer only add sprites.
+const (
+	tiledLayerFloor       = "floor"
+	tiledLayerTopWalls    = "topwalls"
+	tiledLayerSideWalls   = "sidewalls"   // Walls drawn over creeps
+	tiledLayerBottomWalls = "bottomwalls" // Walls drawn over creeps without hiding them
+)
+
+// Types of objects of Tiled maps. The name of creep and item objects is the
+// name of the creep or item spawned.
+const (
+	tiledObjectEntrance = "entrance" // Left tile of the entrance door, in a bottom wall
+	tiledObjectExit     = "exit"     // Left tile of the exit door, in a top wall
+	tiledObjectTorch    = "torch"
+	tiledObjectItem     = "item"
+	tiledObjectCreep    = "creep" // Optionally spawns "count" creeps
+)
+
+// tiledGIDMask masks the flags stored in the global tile IDs of flipped tiles.
+const tiledGIDMask = 0x0FFFFFFF
+
+// tiledSprites are the sprites of each tile of the embedded sprite sheets
+// which may be used by Tiled maps, indexed by the name of the sheet's image
+// and the position of the tile within it.
+var tiledSprites = map[string]map[[2]int]SpriteID{
+	"Tiles-Sandstone-Dungeons.png": {
+		{3, 1}: SpriteFloorA,
+		{2, 0}: SpriteFloorB,
+		{2, 1}: SpriteFloorC,
+		{1, 2}: SpriteWallTop,
+		{1, 6}: SpriteWallBottom,
+		{2, 6}: SpriteWallBottomLeft,
+		{0, 6}: SpriteWallBottomRight,
+		{2, 2}: SpriteWallLeft,
+		{0, 2}: SpriteWallRight,
+		{2, 3}: SpriteWallTopLeft,
+		{0, 3}: SpriteWallTopRight,
+		{8, 4}: SpriteWallPillar,
+		{4, 0}: SpriteBottomDoorClosedL,
+		{5, 0}: SpriteBottomDoorClosedR,
+	},
+	"Tiles-Door-packs.png": {
+		{5, 0}: SpriteTopDoorClosedL,
+		{6, 0}: SpriteTopDoorClosedR,
+		{5, 3}: SpriteTopDoorOpenTL,
+		{6, 3}: SpriteTopDoorOpenTR,
+		{5, 4}: SpriteTopDoorOpenBL,
+		{6, 4}: SpriteTopDoorOpenBR,
+	},
+	"dungeon-tileset-1.png": {
+		{7, 7}:   SpriteGrass11,
+		{8, 7}:   SpriteGrass12,
+		{9, 7}:   SpriteGrass13,
+		{10, 7}:  SpriteGrass14,
+		{11, 7}:  SpriteGrass15,
+		{12, 7}:  SpriteGrass16,
+		{10, 8}:  SpriteGrass21,
+		{10, 9}:  SpriteGrass31,
+		{15, 9}:  SpriteGrass41,
+		{10, 10}: SpriteGrass42,
+		{10, 11}: SpriteGrass51,
+		{10, 12}: SpriteGrass61,
+		{10, 13}: SpriteGrass71,
+		{14, 11}: SpriteGrass81,
+		{10, 14}: SpriteGrass82,
+		{10, 15}: SpriteGrass91,
+		{7, 5}:   SpriteOjasWall1,
+		{9, 13}:  SpriteOjasVent1,
+		{3, 6}:   SpriteOjasDoor11,
+		{3, 7}:   SpriteOjasDoor12,
+	},
+}
+
+// tiledItems are the item types which may be spawned by Tiled maps, by name.
+var tiledItems = map[string]int{
+	"garlic":    ItemTypeGarlic,
+	"holywater": ItemTypeHolyWater,
+}
+
+// TiledMap is a hand-authored map created using the Tiled map editor. Only
+// orthogonal, finite maps with embedded tilesets are supported.
+type TiledMap struct {
+	Width, Height         int // Size in tiles
+	TileWidth, TileHeight int // Size of each tile in pixels
+
+	Tilesets []*TiledTileset
+	Layers   []*TiledTileLayer
+	Objects  []*TiledObject
+}
+
+// TiledTileset is a tileset of a TiledMap.
+type TiledTileset struct {
+	FirstGID int
+	Image    string // Path of the sprite sheet
+	Columns  int
+}
+
+// TiledTileLayer is a tile layer of a TiledMap.
+type TiledTileLayer struct {
+	Name string
+	Data []uint32 // Global ID of each tile, row by row, or zero
+}
+
+// TiledObject is an object of an object layer of a TiledMap.
+type TiledObject struct {
+	Name       string
+	Type       string
+	X, Y       float64 // Position in pixels
+	Height     float64
+	GID        uint32 // Global ID of the tile of tile objects, or zero
+	Properties map[string]string
+}
+
+type tiledJSONProperty struct {
+	Name  string          `json:"name"`
+	Value json.RawMessage `json:"value"`
+}
+
+type tiledJSONObject struct {
+	Name       string              `json:"name"`
+	Type       string              `json:"type"`
+	Class      string              `json:"class"`
+	X          float64             `json:"x"`
+	Y          float64             `json:"y"`
+	Height     float64             `json:"height"`
+	GID        uint32              `json:"gid"`
+	Properties []tiledJSONProperty `json:"properties"`
+}
+
+type tiledJSONLayer struct {
+	Name     string            `json:"name"`
+	Type     string            `json:"type"`
+	Encoding string            `json:"encoding"`
+	Data     json.RawMessage   `json:"data"`
+	Objects  []tiledJSONObject `json:"objects"`
+}
+
+type tiledJSONMap struct {
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	TileWidth   int    `json:"tilewidth"`
+	TileHeight  int    `json:"tileheight"`
+	Orientation string `json:"orientation"`
+	Infinite    bool   `json:"infinite"`
+	Tilesets    []struct {
+		FirstGID int    `json:"firstgid"`
+		Source   string `json:"source"`
+		Image    string `json:"image"`
+		Columns  int    `json:"columns"`
+	} `json:"tilesets"`
+	Layers []tiledJSONLayer `json:"layers"`
+}
+
+// ReadTiledJSON reads a map saved by Tiled in the JSON format.
+func ReadTiledJSON(r io.Reader) (*TiledMap, error) {
+	var jm tiledJSONMap
+	err := json.NewDecoder(r).Decode(&jm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read map: %s", err)
+	} else if jm.Orientation != "orthogonal" || jm.Infinite {
+		return nil, errors.New("failed to read map: only orthogonal, finite maps are supported")
+	}
+
+	m := &TiledMap{
+		Width:      jm.Width,
+		Height:     jm.Height,
+		TileWidth:  jm.TileWidth,
+		TileHeight: jm.TileHeight,
+	}
+	for _, ts := range jm.Tilesets {
+		if ts.Source != "" {
+			return nil, fmt.Errorf("failed to read map: external tileset %s is not supported", ts.Source)
+		}
+		m.Tilesets = append(m.Tilesets, &TiledTileset{
+			FirstGID: ts.FirstGID,
+			Image:    ts.Image,
+			Columns:  ts.Columns,
+		})
+	}
+	for _, jl := range jm.Layers {
+		switch jl.Type {
+		case "tilelayer":
+			if jl.Encoding != "" && jl.Encoding != "csv" {
+				return nil, fmt.Errorf("failed to read map: layer %s uses unsupported encoding %s", jl.Name, jl.Encoding)
+			}
+			layer := &TiledTileLayer{Name: jl.Name}
+			err = json.Unmarshal(jl.Data, &layer.Data)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read map: invalid data in layer %s: %s", jl.Name, err)
+			}
+			m.Layers = append(m.Layers, layer)
+		case "objectgroup":
+			for _, jo := range jl.Objects {
+				o := &TiledObject{
+					Name:       jo.Name,
+					Type:       jo.Type,
+					X:          jo.X,
+					Y:          jo.Y,
+					Height:     jo.Height,
+					GID:        jo.GID,
+					Properties: make(map[string]string),
+				}
+				if o.Type == "" {
+					o.Type = jo.Class
+				}
+				for _, p := range jo.Properties {
+					var s string
+					if json.Unmarshal(p.Value, &s) != nil {
+						s = string(p.Value)
+					}
+					o.Properties[p.Name] = s
+				}
+				m.Objects = append(m.Objects, o)
+			}
+		}
+	}
+	return m, nil
+}
+
+type tmxProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type tmxObject struct {
+	Name       string        `xml:"name,attr"`
+	Type       string        `xml:"type,attr"`
+	Class      string        `xml:"class,attr"`
+	X          float64       `xml:"x,attr"`
+	Y          float64       `xml:"y,attr"`
+	Height     float64       `xml:"height,attr"`
+	GID        uint32        `xml:"gid,attr"`
+	Properties []tmxProperty `xml:"properties>property"`
+}
+
+type tmxMap struct {
+	Width       int    `xml:"width,attr"`
+	Height      int    `xml:"height,attr"`
+	TileWidth   int    `xml:"tilewidth,attr"`
+	TileHeight  int    `xml:"tileheight,attr"`
+	Orientation string `xml:"orientation,attr"`
+	Infinite    int    `xml:"infinite,attr"`
+	Tilesets    []struct {
+		FirstGID int    `xml:"firstgid,attr"`
+		Source   string `xml:"source,attr"`
+		Columns  int    `xml:"columns,attr"`
+		Image    struct {
+			Source string `xml:"source,attr"`
+		} `xml:"image"`
+	} `xml:"tileset"`
+	Layers []struct {
+		Name string `xml:"name,attr"`
+		Data struct {
+			Encoding string `xml:"encoding,attr"`
+			Value    string `xml:",chardata"`
+		} `xml:"data"`
+	} `xml:"layer"`
+	ObjectGroups []struct {
+		Objects []tmxObject `xml:"object"`
+	} `xml:"objectgroup"`
+}
+
+// ReadTMX reads a map saved by Tiled in the TMX format. Tile layers must be
+// encoded as CSV.
+func ReadTMX(r io.Reader) (*TiledMap, error) {
+	var tm tmxMap
+	err := xml.NewDecoder(r).Decode(&tm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read map: %s", err)
+	} else if tm.Orientation != "orthogonal" || tm.Infinite != 0 {
+		return nil, errors.New("failed to read map: only orthogonal, finite maps are supported")
+	}
+
+	m := &TiledMap{
+		Width:      tm.Width,
+		Height:     tm.Height,
+		TileWidth:  tm.TileWidth,
+		TileHeight: tm.TileHeight,
+	}
+	for _, ts := range tm.Tilesets {
+		if ts.Source != "" {
+			return nil, fmt.Errorf("failed to read map: external tileset %s is not supported", ts.Source)
+		}
+		m.Tilesets = append(m.Tilesets, &TiledTileset{
+			FirstGID: ts.FirstGID,
+			Image:    ts.Image.Source,
+			Columns:  ts.Columns,
+		})
+	}
+	for _, tl := range tm.Layers {
+		if tl.Data.Encoding != "csv" {
+			return nil, fmt.Errorf("failed to read map: layer %s uses unsupported encoding %s", tl.Name, tl.Data.Encoding)
+		}
+		layer := &TiledTileLayer{Name: tl.Name}
+		for _, v := range strings.Split(tl.Data.Value, ",") {
+			gid, err := strconv.ParseUint(strings.TrimSpace(v), 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read map: invalid data in layer %s: %s", tl.Name, err)
+			}
+			layer.Data = append(layer.Data, uint32(gid))
+		}
+		m.Layers = append(m.Layers, layer)
+	}
+	for _, og := range tm.ObjectGroups {
+		for _, to := range og.Objects {
+			o := &TiledObject{
+				Name:       to.Name,
+				Type:       to.Type,
+				X:          to.X,
+				Y:          to.Y,
+				Height:     to.Height,
+				GID:        to.GID,
+				Properties: make(map[string]string),
+			}
+			if o.Type == "" {
+				o.Type = to.Class
+			}
+			for _, p := range to.Properties {
+				o.Properties[p.Name] = p.Value
+			}
+			m.Objects = append(m.Objects, o)
+		}
+	}
+	return m, nil
+}
+
+// loadTiledMap loads an embedded map by name. Maps are read in the TMX or JSON
+// format depending on their extension.
+func loadTiledMap(name string) (*TiledMap, error) {
+	f, err := mapsFS.Open(path.Join("maps", name))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch path.Ext(name) {
+	case ".tmx":
+		return ReadTMX(f)
+	case ".json", ".tmj":
+		return ReadTiledJSON(f)
+	default:
+		return nil, fmt.Errorf("unsupported map format %s", name)
+	}
+}
+
+// sprite returns the sprite of a tile of the map.
+func (m *TiledMap) sprite(gid uint32) (SpriteID, error) {
+	gid &= tiledGIDMask
+	var tileset *TiledTileset
+	for _, ts := range m.Tilesets {
+		if int(gid) >= ts.FirstGID && (tileset == nil || ts.FirstGID > tileset.FirstGID) {
+			tileset = ts
+		}
+	}
+	if tileset == nil {
+		return SpriteNone, fmt.Errorf("unknown tile %d", gid)
+	}
+
+	sheet := path.Base(tileset.Image)
+	sprites, ok := tiledSprites[sheet]
+	if !ok {
+		return SpriteNone, fmt.Errorf("unsupported tileset %s", tileset.Image)
+	} else if tileset.Columns <= 0 {
+		return SpriteNone, fmt.Errorf("invalid tileset %s", tileset.Image)
+	}
+	id := int(gid) - tileset.FirstGID
+	x, y := id%tileset.Columns, id/tileset.Columns
+	sprite, ok := sprites[[2]int{x, y}]
+	if !ok {
+		return SpriteNone, fmt.Errorf("unsupported tile %d,%d of tileset %s", x, y, sheet)
+	}
+	return sprite, nil
+}
+
+// position returns the position of an object in tiles. The center of each
+// tile is at whole coordinates.
+func (m *TiledMap) position(o *TiledObject) (float64, float64) {
+	x, y := o.X/float64(m.TileWidth), o.Y/float64(m.TileHeight)
+	if o.GID != 0 {
+		// Tile objects are positioned by their bottom left corner.
+		return x, (o.Y - o.Height) / float64(m.TileHeight)
+	}
+	return x - 0.5, y - 0.5
+}
+
+// NewTiledLevel returns a new Level built from a Tiled map. Players enter the
+// level through its entrance, which must be defined by the map. Creeps and
+// items defined by the map are spawned. All random decisions are made using
+// the provided source.
+func NewTiledLevel(levelNum int, m *TiledMap, players []*Player, rng *rand.Rand, clock *Clock) (*Level, error) {
+	def := levelDefinition(levelNum)
+	if def == nil {
+		return nil, fmt.Errorf("unknown level %d", levelNum)
+	} else if m.Width < 3 || m.Height < 5 || m.TileWidth <= 0 || m.TileHeight <= 0 {
+		return nil, fmt.Errorf("invalid map size %dx%d", m.Width, m.Height)
+	}
+
+	l := &Level{
+		Num:           levelNum,
+		W:             m.Width,
+		H:             m.Height,
+		TileSize:      32,
+		RequiredSouls: def.RequiredSouls,
+		Players:       players,
+		rng:           rng,
+		clock:         clock,
+	}
+	l.grid = newSpatialHash(l.W, l.H)
+
+	l.Tiles = make([][]*Tile, l.H)
+	l.TopWalls = make([][]*Tile, l.H)
+	l.SideWalls = make([][]*Tile, l.H)
+	l.OtherWalls = make([][]*Tile, l.H)
+	for y := 0; y < l.H; y++ {
+		l.Tiles[y] = make([]*Tile, l.W)
+		l.TopWalls[y] = make([]*Tile, l.W)
+		l.SideWalls[y] = make([]*Tile, l.W)
+		l.OtherWalls[y] = make([]*Tile, l.W)
+		for x := 0; x < l.W; x++ {
+			l.Tiles[y][x] = &Tile{}
+		}
+	}
+
+	for _, layer := range m.Layers {
+		if len(layer.Data) != l.W*l.H {
+			return nil, fmt.Errorf("layer %s does not match map size", layer.Name)
+		}
+		for i, gid := range layer.Data {
+			if gid == 0 {
+				continue
+			}
+			x, y := i%l.W, i/l.W
+			sprite, err := m.sprite(gid)
+			if err != nil {
+				return nil, fmt.Errorf("invalid tile at %d,%d of layer %s: %s", x, y, layer.Name, err)
+			}
+			t := l.Tiles[y][x]
+			t.AddSprite(sprite)
+
+			switch layer.Name {
+			case tiledLayerFloor:
+				t.Floor = true
+			case tiledLayerTopWalls:
+				t.Wall = true
+				l.TopWalls[y][x] = t
+			case tiledLayerSideWalls:
+				t.Wall = true
+				l.SideWalls[y][x] = t
+				l.OtherWalls[y][x] = t
+			case tiledLayerBottomWalls:
+				t.Wall = true
+				l.OtherWalls[y][x] = t
+			}
+		}
+	}
+
+	var entrance, exit bool
+	for _, o := range m.Objects {
+		x, y := m.position(o)
+		tx, ty := int(math.Floor(x+0.5)), int(math.Floor(y+0.5))
+		if l.Tile(tx, ty) == nil {
+			return nil, fmt.Errorf("%s object %s is outside of the map", o.Type, o.Name)
+		}
+
+		switch o.Type {
+		case tiledObjectEntrance:
+			l.EnterX, l.EnterY = tx, ty
+			entrance = true
+		case tiledObjectExit:
+			l.ExitX, l.ExitY = tx, ty
+			exit = true
+		case tiledObjectTorch:
+			c := newCreepAt(TypeTorch, l, x, y)
+			l.addCreep(c)
+			l.Torches = append(l.Torches, c)
+		case tiledObjectItem:
+			itemType, ok := tiledItems[o.Name]
+			if !ok {
+				return nil, fmt.Errorf("unknown item %s", o.Name)
+			}
+			l.addItem(&Item{
+				ItemType: itemType,
+				X:        x,
+				Y:        y,
+				level:    l,
+				Health:   1,
+			})
+		case tiledObjectCreep:
+			creepType, ok := creepTypeByName(o.Name)
+			if !ok {
+				return nil, fmt.Errorf("unknown creep %s", o.Name)
+			}
+			count := 1
+			if v, ok := o.Properties["count"]; ok {
+				var err error
+				count, err = strconv.Atoi(v)
+				if err != nil {
+					return nil, fmt.Errorf("invalid count of creep %s: %s", o.Name, err)
+				}
+			}
+			for i := 0; i < count; i++ {
+				if CreepDefinitions[creepType].Boss {
+					l.addBoss(creepType, x, y)
+					continue
+				}
+				l.addCreep(newCreepAt(creepType, l, x, y))
+			}
+		default:
+			return nil, fmt.Errorf("unknown object type %s", o.Type)
+		}
+	}
+
+	// The exit opens into the two tiles above it, and players enter the level
+	// above the entrance.
+	if !entrance || !exit {
+		return nil, errors.New("map has no entrance or exit")
+	} else if l.ExitY < 2 || l.ExitY >= l.H-1 || l.ExitX >= l.W-1 {
+		return nil, errors.New("map exit is too close to the edge of the map")
+	} else if l.EnterY < 1 || !l.Tiles[l.EnterY-1][l.EnterX].Floor {
+		return nil, errors.New("map entrance does not lead to floor")
+	}
+
+	l.BakeLightmap()
+
+	return l, nil
+}
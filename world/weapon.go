@@ -0,0 +1,18 @@
+package world
+
+import (
+	"time"
+)
+
+// Weapon represents a weapon held by the player.
+type Weapon struct {
+	LastFire time.Time
+	Cooldown time.Duration
+}
+
+// NewUzi returns a new uzi.
+func NewUzi() *Weapon {
+	return &Weapon{
+		Cooldown: 100 * time.Millisecond,
+	}
+}
@@ -0,0 +1,135 @@
+package world
+
+import (
+	"math/rand"
+)
+
+// NewWinLevel returns the Level shown after the final level is completed. The
+// provided player walks out of the dungeon.
+func NewWinLevel(p *Player, rng *rand.Rand, clock *Clock) *Level {
+	l := &Level{
+		W:        256,
+		H:        256,
+		TileSize: 32,
+		Players:  []*Player{p},
+		rng:      rng,
+		clock:    clock,
+	}
+	l.grid = newSpatialHash(l.W, l.H)
+
+	startX, startY := 108, 108
+
+	grid := make([][][]SpriteID, l.W)
+	for x := 0; x < l.W; x++ {
+		grid[x] = make([][]SpriteID, l.H)
+	}
+
+	// Add ground.
+	var lastBones int
+	bonesDistance := 7
+	for x := 0; x < l.W; x++ {
+		excludeBones := x-lastBones < bonesDistance
+
+		r := l.rng.Intn(33)
+		switch r {
+		case 0:
+			grid[x][startY] = []SpriteID{
+				SpriteGrass11,
+			}
+		case 1:
+			grid[x][startY] = []SpriteID{
+				SpriteGrass12,
+			}
+		case 2:
+			grid[x][startY] = []SpriteID{
+				SpriteGrass13,
+			}
+		case 3:
+			grid[x][startY] = []SpriteID{
+				SpriteGrass14,
+			}
+		case 4:
+			grid[x][startY] = []SpriteID{
+				SpriteGrass15,
+			}
+		case 5:
+			grid[x][startY] = []SpriteID{
+				SpriteGrass16,
+			}
+		}
+		grid[x][startY+1] = []SpriteID{
+			SpriteGrass21,
+		}
+		grid[x][startY+2] = []SpriteID{
+			SpriteGrass31,
+		}
+		if l.rng.Intn(33) != 0 || excludeBones {
+			grid[x][startY+3] = []SpriteID{
+				SpriteGrass41,
+			}
+		} else {
+			grid[x][startY+3] = []SpriteID{
+				SpriteGrass42,
+			}
+		}
+		grid[x][startY+4] = []SpriteID{
+			SpriteGrass51,
+		}
+		grid[x][startY+5] = []SpriteID{
+			SpriteGrass61,
+		}
+		grid[x][startY+6] = []SpriteID{
+			SpriteGrass71,
+		}
+		if l.rng.Intn(33) != 0 || excludeBones {
+			grid[x][startY+7] = []SpriteID{
+				SpriteGrass81,
+			}
+		} else {
+			grid[x][startY+7] = []SpriteID{
+				SpriteGrass82,
+			}
+		}
+		grid[x][startY+8] = []SpriteID{
+			SpriteGrass91,
+		}
+	}
+
+	// Add dungeon.
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 64; y++ {
+			grid[startX-x-1][startY-y] = []SpriteID{
+				SpriteOjasWall1,
+			}
+
+			if y == 0 && x%8 == 0 {
+				grid[startX-x-1][startY-y] = append(grid[startX-x-1][startY-y], SpriteOjasVent1)
+			}
+		}
+	}
+
+	grid[startX-1][startY-1] = append(grid[startX-1][startY-1], SpriteOjasDoor11)
+	grid[startX-1][startY] = append(grid[startX-1][startY], SpriteOjasDoor12)
+
+	// Add sprites to tiles.
+	l.Tiles = make([][]*Tile, l.H)
+	for y := 0; y < l.H; y++ {
+		l.Tiles[y] = make([]*Tile, l.W)
+		for x := 0; x < l.W; x++ {
+			t := &Tile{}
+			for _, sprite := range grid[x][y] {
+				t.AddSprite(sprite)
+			}
+			l.Tiles[y][x] = t
+		}
+	}
+
+	l.BakeLightmap()
+
+	doorX := float64(startX) - 0.4
+
+	p.Angle = 0
+	p.X, p.Y = doorX, float64(startY)
+
+	return l
+}
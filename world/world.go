@@ -0,0 +1,802 @@
+package world
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"golang.org/x/image/colornames"
+)
+
+const (
+	garlicActiveTime = 7 * time.Second
+
+	creepSoundDelay = 250 * time.Millisecond
+)
+
+// World represents the state of a game. It is advanced one tick at a time
+// using Step, and does not depend on any window, input device or graphics.
+type World struct {
+	LevelNum int
+	Level    *Level
+
+	// Players in the order they joined. Player input is provided to Step in
+	// the same order.
+	Players []*Player
+
+	// Souls rescued by all players during the current level.
+	SoulsRescued int
+
+	// Souls eaten by creeps during the current level.
+	SoulsEaten int
+
+	// Statistics of each completed level.
+	CompletedLevels []LevelStats
+
+	Projectiles []*Projectile
+
+	Clock *Clock
+
+	Seed int64
+
+	GodMode    bool
+	NoclipMode bool
+
+	// Difficulty of the game, which scales the spawning of creeps.
+	Difficulty int
+
+	// Name of the generator of levels. The dungeon generator is used when no
+	// generator is specified.
+	Generator string
+
+	// Distances from players within which creeps are fully simulated, and
+	// beyond which unaware creeps sleep.
+	SimulationRadius float64
+	SleepRadius      float64
+
+	// Events which happened since they were last cleared.
+	Events []Event
+
+	src *source
+	rng *rand.Rand
+
+	lastCreepSound time.Time
+
+	director director
+}
+
+// NewWorld returns a new World. Reset must be called before the World is
+// stepped.
+func NewWorld() (*World, error) {
+	p, err := NewPlayer()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &World{
+		Players:    []*Player{p},
+		Clock:      NewClock(),
+		Difficulty: DifficultyNormal,
+
+		SimulationRadius: DefaultSimulationRadius,
+		SleepRadius:      DefaultSleepRadius,
+	}
+	return w, nil
+}
+
+// Reset starts a new game using the provided seed. Only the first player
+// remains, additional players join again when their input is provided to Step.
+func (w *World) Reset(seed int64) error {
+	w.Seed = seed
+	w.src = &source{}
+	w.src.Seed(seed)
+	w.rng = rand.New(w.src)
+
+	w.Clock.Reset()
+
+	w.LevelNum = 1
+
+	w.Events = nil
+
+	p := w.Players[0]
+	p.HasTorch = true
+	p.Weapon = NewUzi()
+	p.GarlicUntil = time.Time{}
+	p.HolyWaterUntil = time.Time{}
+	w.Players = w.Players[:1]
+
+	w.lastCreepSound = time.Time{}
+
+	err := w.GenerateLevel()
+	if err != nil {
+		return err
+	}
+
+	// Reset player score.
+	p.Score = 0
+
+	// Reset souls rescued.
+	w.SoulsRescued = 0
+	w.SoulsEaten = 0
+	w.CompletedLevels = nil
+
+	// Reset player health.
+	p.Health = StartingHealth
+
+	return nil
+}
+
+// AddPlayer adds a player to the game at the position of the first living
+// player.
+func (w *World) AddPlayer() (*Player, error) {
+	p, err := NewPlayer()
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range w.Players {
+		if other.Health > 0 {
+			p.X, p.Y, p.Angle = other.X, other.Y, other.Angle
+			break
+		}
+	}
+	w.Players = append(w.Players, p)
+	if w.Level != nil {
+		w.Level.Players = w.Players
+	}
+	return p, nil
+}
+
+// Alive returns whether any player is alive.
+func (w *World) Alive() bool {
+	for _, p := range w.Players {
+		if p.Health > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *World) newItem(itemType int) *Item {
+	x, y := w.Level.NewSpawnLocation()
+	return &Item{
+		ItemType: itemType,
+		X:        x,
+		Y:        y,
+		level:    w.Level,
+		Health:   1,
+	}
+}
+
+// NextLevel advances to the next level. An EventWin is added after the final
+// level is completed.
+func (w *World) NextLevel() error {
+	w.SoulsRescued = 0
+	w.SoulsEaten = 0
+
+	// Revive dead players.
+	for _, p := range w.Players {
+		if p.Health <= 0 {
+			p.Health = 1
+		}
+	}
+
+	w.LevelNum++
+	if w.LevelDefinition() == nil {
+		w.addEvent(Event{EventType: EventWin})
+		return nil
+	}
+	return w.GenerateLevel()
+}
+
+// Warp advances to the specified level, skipping any levels before it.
+func (w *World) Warp(levelNum int) error {
+	w.LevelNum = levelNum - 1
+	return w.NextLevel()
+}
+
+// GenerateLevel generates the current level and positions the players in it.
+func (w *World) GenerateLevel() error {
+	// Remove projectiles.
+	w.Projectiles = nil
+
+	// Remove creeps.
+	if w.Level != nil {
+		w.Level.Creeps = nil
+	}
+
+	def := w.LevelDefinition()
+	if def == nil {
+		return fmt.Errorf("unknown level %d", w.LevelNum)
+	}
+
+	var err error
+	if def.Map != "" {
+		var m *TiledMap
+		m, err = loadTiledMap(def.Map)
+		if err == nil {
+			w.Level, err = NewTiledLevel(w.LevelNum, m, w.Players, w.rng, w.Clock)
+		}
+	} else {
+		var gen LevelGenerator
+		gen, err = NewLevelGenerator(w.Generator, def)
+		if err != nil {
+			return err
+		}
+		w.Level, err = NewLevel(w.LevelNum, gen, w.Players, w.rng, w.Clock)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to create new level: %s", err)
+	}
+
+	// Position players.
+	p := w.Players[0]
+	if w.LevelNum > 1 || def.Map != "" {
+		p.X, p.Y = float64(w.Level.EnterX)+0.5, float64(w.Level.EnterY)-0.5
+	} else {
+		for {
+			p.X, p.Y = float64(w.rng.Intn(w.Level.W)), float64(w.rng.Intn(w.Level.H))
+			if w.Level.IsFloor(p.X, p.Y) {
+				break
+			}
+		}
+	}
+	for _, other := range w.Players[1:] {
+		other.X, other.Y = p.X, p.Y
+	}
+
+	// Spawn items.
+	for i := 0; i < def.Garlic; i++ {
+		itemType := ItemTypeGarlic
+		c := w.newItem(itemType)
+		w.Level.addItem(c)
+	}
+	// Spawn starting garlic. Maps place their own items.
+	if def.Map == "" {
+		item := w.newItem(ItemTypeGarlic)
+		for {
+			garlicOffsetA := 8 - float64(w.rng.Intn(16))
+			garlicOffsetB := 8 - float64(w.rng.Intn(16))
+			startingGarlicX := p.X + 2 + garlicOffsetA
+			startingGarlicY := p.Y + 2 + garlicOffsetB
+
+			if w.Level.IsFloor(startingGarlicX, startingGarlicY) {
+				item.X = startingGarlicX
+				item.Y = startingGarlicY
+				break
+			}
+		}
+		w.Level.addItem(item)
+	}
+
+	// Spawn starting creeps.
+	cfg := w.spawnConfig()
+	w.director = director{
+		NextWave: float64(cfg.WaveInterval),
+	}
+	for i := 0; i < cfg.StartingCreeps; i++ {
+		w.Level.AddCreep(TypeVampire)
+	}
+
+	// Guard the exit, unless a boss was placed by the map.
+	if def.Boss != "" && w.Level.Boss == nil {
+		bossType, _ := creepTypeByName(def.Boss)
+		w.Level.AddBoss(bossType)
+	}
+	return nil
+}
+
+// CheckLevelComplete opens the exit once enough souls have been rescued and
+// the boss guarding it, if any, has been killed.
+func (w *World) CheckLevelComplete() {
+	if w.SoulsRescued < w.Level.RequiredSouls || !w.Level.ExitOpenTime.IsZero() {
+		return
+	}
+	if w.Level.Boss != nil && w.Level.Boss.Health > 0 {
+		return
+	}
+	w.Level.ExitOpenTime = w.Clock.Now()
+
+	// TODO preserve existing floor sprite
+
+	t := w.Level.Tiles[w.Level.ExitY][w.Level.ExitX]
+	t.Sprites = nil
+	t.AddSprite(SpriteFloorA)
+	t.AddSprite(SpriteTopDoorOpenTL)
+
+	t = w.Level.Tiles[w.Level.ExitY][w.Level.ExitX+1]
+	t.Sprites = nil
+	t.AddSprite(SpriteFloorA)
+	t.AddSprite(SpriteTopDoorOpenTR)
+
+	t = w.Level.Tiles[w.Level.ExitY+1][w.Level.ExitX]
+	t.Sprites = nil
+	t.AddSprite(SpriteFloorA)
+	t.AddSprite(SpriteTopDoorOpenBL)
+
+	t = w.Level.Tiles[w.Level.ExitY+1][w.Level.ExitX+1]
+	t.Sprites = nil
+	t.AddSprite(SpriteFloorA)
+	t.AddSprite(SpriteTopDoorOpenBR)
+
+	for i := 1; i < 3; i++ {
+		t = w.Level.Tiles[w.Level.ExitY-i][w.Level.ExitX]
+		t.ForceColorScale = 0
+
+		t = w.Level.Tiles[w.Level.ExitY-i][w.Level.ExitX+1]
+		t.ForceColorScale = 0
+	}
+
+	w.addEvent(Event{EventType: EventExitOpen})
+
+	// TODO add trigger entity or hardcode check
+}
+
+// Step advances the simulation by a single tick using the provided input of
+// each player. When input is provided for more players than are in the game,
+// the additional players join the game.
+func (w *World) Step(inputs []Input) error {
+	for len(w.Players) < len(inputs) {
+		_, err := w.AddPlayer()
+		if err != nil {
+			return err
+		}
+	}
+
+	if !w.Alive() || w.LevelDefinition() == nil {
+		return nil
+	}
+
+	for _, in := range inputs {
+		if in.Cheat == CheatNone {
+			continue
+		}
+		err := w.applyCheat(in.Cheat)
+		if err != nil {
+			return err
+		}
+		if w.LevelDefinition() == nil || in.Cheat == CheatWin {
+			return nil
+		}
+	}
+
+	w.resetExpiredTimers()
+
+	w.Level.updateFlowField()
+
+	tick := w.Clock.Tick()
+
+	liveCreeps, nearbyCreeps, farCreeps, sleepingCreeps := 0, 0, 0, 0
+	for _, c := range w.Level.Creeps {
+		if c.Health == 0 {
+			if c.Dying() {
+				c.animate()
+			}
+			continue
+		}
+
+		c.Simulation = w.simulationLevel(c)
+		if c.Simulation != SimulationFull {
+			if c.Simulation == SimulationFar {
+				// Spread the updates of far creeps across ticks.
+				if (tick+c.id)%farUpdateTicks == 0 {
+					c.updateFar(farUpdateTicks)
+					w.Level.grid.updateCreep(c)
+				}
+				farCreeps++
+			} else {
+				sleepingCreeps++
+			}
+			c.animate()
+			liveCreeps++
+			continue
+		}
+
+		c.Update()
+		c.animate()
+		w.Level.grid.updateCreep(c)
+
+		def := c.Definition()
+		if def.Static {
+			continue
+		}
+
+		if def.Ranged != nil {
+			w.creepFire(c)
+		}
+		if def.Feeding != nil {
+			w.feed(c)
+		}
+
+		biteThreshold := def.BiteRadius
+
+		// TODO can this move into creep?
+		cx, cy := c.Position()
+		var nearPlayer, pressing bool
+		for _, p := range w.Players {
+			if p.Health <= 0 {
+				continue
+			}
+
+			dx, dy := DeltaXY(p.X, p.Y, cx, cy)
+			if dx <= pressureRadius && dy <= pressureRadius && !def.Collectable {
+				pressing = true
+			}
+			if dx <= biteThreshold && dy <= biteThreshold {
+				if def.Collectable {
+					w.SoulsRescued++
+					p.Score += def.Score
+					w.HurtCreep(c, -1, nil)
+					w.CheckLevelComplete()
+					break
+				} else if w.vulnerable(p) && !c.Staggered() {
+					if c.boss == nil {
+						w.HurtCreep(c, -1, nil)
+					} else if !w.bossBite(c) {
+						continue
+					}
+
+					w.hurtPlayer(p, c)
+					break
+				}
+			} else if def.AmbientSound != "" && dx <= def.AmbientRange[0] && dy <= def.AmbientRange[1] {
+				nearPlayer = true
+			}
+		}
+		if nearPlayer && c.Health > 0 && w.rng.Intn(166) == 6 && w.Clock.Since(w.lastCreepSound) >= creepSoundDelay {
+			w.addEvent(Event{EventType: EventCreepSound, X: c.X, Y: c.Y, Creep: c})
+			w.lastCreepSound = w.Clock.Now()
+		}
+
+		if c.Health > 0 {
+			liveCreeps++
+			if pressing {
+				nearbyCreeps++
+			}
+		}
+	}
+	w.Level.LiveCreeps = liveCreeps
+	w.Level.FarCreeps = farCreeps
+	w.Level.SleepingCreeps = sleepingCreeps
+	w.director.Nearby = nearbyCreeps
+
+	w.updateBoss()
+
+	pan := 0.05
+
+	for i, p := range w.Players {
+		if p.Health <= 0 || i >= len(inputs) {
+			continue
+		}
+		in := inputs[i]
+
+		// Move player.
+		px, py := p.X+in.MoveX*pan, p.Y+in.MoveY*pan
+		if w.NoclipMode || w.Level.IsFloor(px, py) {
+			p.X, p.Y = px, py
+		} else if w.Level.IsFloor(px, p.Y) {
+			p.X = px
+		} else if w.Level.IsFloor(p.X, py) {
+			p.Y = py
+		}
+
+		w.Level.itemBuf = w.Level.grid.queryItems(p.X, p.Y, 1, w.Level.itemBuf)
+		for _, item := range w.Level.itemBuf {
+			if item.Health == 0 {
+				continue
+			}
+
+			dx, dy := DeltaXY(p.X, p.Y, item.X, item.Y)
+			if dx <= 1 && dy <= 1 {
+				w.Level.removeItem(item)
+				p.Score += item.useScore() * w.LevelNum
+
+				if item.ItemType == ItemTypeGarlic {
+					p.GarlicUntil = w.Clock.Now().Add(garlicActiveTime)
+				} else if item.ItemType == ItemTypeHolyWater {
+					p.Health++
+				}
+
+				w.addEvent(Event{EventType: EventPickup, X: item.X, Y: item.Y, Item: item, Player: p})
+			}
+		}
+
+		// Update player angle.
+		p.Angle = in.Angle
+	}
+
+	// Update boolets.
+	bulletHitThreshold := 0.501
+	bulletSeekThreshold := 2.0
+	removed := 0
+UPDATEPROJECTILES:
+	for i, p := range w.Projectiles {
+		if p.Speed == 0 {
+			continue
+		}
+		speed := p.Speed
+		for {
+			bx := p.X + math.Cos(p.Angle)*speed
+			by := p.Y + math.Sin(p.Angle)*speed
+
+			if w.Level.IsFloor(bx, by) {
+				p.X, p.Y = bx, by
+				break
+			}
+
+			speed *= .25
+			if speed < .001 {
+				// Remove projectile
+				p.Speed = 0
+				p.ColorScale = .01
+				continue UPDATEPROJECTILES
+			}
+		}
+
+		if p.Faction == FactionCreeps {
+			for _, player := range w.Players {
+				if player.Health <= 0 {
+					continue
+				}
+
+				dx, dy := DeltaXY(p.X, p.Y, player.X, player.Y)
+				if dx > bulletHitThreshold || dy > bulletHitThreshold {
+					continue
+				}
+
+				w.hurtPlayer(player, p.Creep)
+
+				// Remove projectile
+				w.Projectiles = append(w.Projectiles[:i-removed], w.Projectiles[i-removed+1:]...)
+				removed++
+
+				continue UPDATEPROJECTILES
+			}
+			continue
+		}
+
+		w.Level.creepBuf = w.Level.grid.queryCreeps(p.X, p.Y, bulletSeekThreshold, w.Level.creepBuf)
+		for _, c := range w.Level.creepBuf {
+			if c.Health == 0 || c.Definition().Collectable {
+				continue
+			}
+
+			cx, cy := c.Position()
+			dx, dy := DeltaXY(p.X, p.Y, cx, cy)
+			if dx > bulletHitThreshold || dy > bulletHitThreshold {
+				if dx < bulletSeekThreshold && dy < bulletSeekThreshold && p.Player != nil {
+					// Creeps hear bullets passing nearby.
+					c.alert(p.Player.X, p.Player.Y)
+				}
+				continue
+			}
+
+			w.shootCreep(c, p)
+
+			// Remove projectile
+			w.Projectiles = append(w.Projectiles[:i-removed], w.Projectiles[i-removed+1:]...)
+			removed++
+
+			continue UPDATEPROJECTILES
+		}
+	}
+
+	// Fire boolets.
+	for i, p := range w.Players {
+		if p.Health <= 0 || i >= len(inputs) || !inputs[i].Fire || p.Weapon == nil || w.Clock.Since(p.Weapon.LastFire) < p.Weapon.Cooldown {
+			continue
+		}
+
+		w.Projectiles = append(w.Projectiles, &Projectile{
+			X:          p.X,
+			Y:          p.Y,
+			Angle:      p.Angle,
+			Speed:      0.35,
+			Color:      colornames.Yellow,
+			ColorScale: 1.0,
+			Player:     p,
+		})
+
+		p.Weapon.LastFire = w.Clock.Now()
+
+		w.addEvent(Event{EventType: EventFire, X: p.X, Y: p.Y, Player: p})
+	}
+
+	// Remove dead creeps.
+	if tick%200 == 0 {
+		creeps := w.Level.Creeps[:0]
+		for _, creep := range w.Level.Creeps {
+			if creep.Health != 0 || creep.Definition().Static || creep.Definition().Collectable || creep.Dying() {
+				creeps = append(creeps, creep)
+				continue
+			}
+
+			// Remove creep.
+			w.Level.grid.removeCreep(creep)
+		}
+		w.Level.Creeps = creeps
+	}
+
+	// Spawn garlic.
+	if (tick > 0 && tick%(144*45) == 0) || w.rng.Intn(6666) == 0 {
+		item := w.newItem(ItemTypeGarlic)
+		w.Level.Items = append(w.Level.Items, item)
+
+	SPAWNGARLIC:
+		for i := 0; i < 5; i++ {
+			for _, levelItem := range w.Level.Items {
+				if levelItem != item && item.ItemType == ItemTypeGarlic {
+					dx, dy := DeltaXY(item.X, item.Y, levelItem.X, levelItem.Y)
+					if dx < 21 || dy < 21 {
+						item.X, item.Y = w.Level.NewSpawnLocation()
+						continue SPAWNGARLIC
+					}
+				}
+			}
+			break
+		}
+		w.Level.grid.addItem(item)
+
+		w.addEvent(Event{EventType: EventMessage, Message: "SPAWN GARLIC"})
+	}
+
+	// Spawn holy water.
+	if tick%(144*30) == 0 || w.rng.Intn(6666) == 0 {
+		item := w.newItem(ItemTypeHolyWater)
+		w.Level.Items = append(w.Level.Items, item)
+
+	SPAWNHOLYWATER:
+		for i := 0; i < 5; i++ {
+			for _, levelItem := range w.Level.Items {
+				if levelItem != item && item.ItemType == ItemTypeHolyWater {
+					dx, dy := DeltaXY(item.X, item.Y, levelItem.X, levelItem.Y)
+					if dx < 21 || dy < 21 {
+						item.X, item.Y = w.Level.NewSpawnLocation()
+						continue SPAWNHOLYWATER
+					}
+				}
+			}
+			break
+		}
+		w.Level.grid.addItem(item)
+
+		w.addEvent(Event{EventType: EventMessage, Message: "SPAWN HOLY WATER"})
+	}
+
+	w.direct()
+
+	// Check if a player is exiting level.
+	if !w.Level.ExitOpenTime.IsZero() {
+		exitThreshold := 1.1
+		for _, p := range w.Players {
+			if p.Health <= 0 {
+				continue
+			}
+			dx1, dy1 := DeltaXY(p.X, p.Y, float64(w.Level.ExitX), float64(w.Level.ExitY))
+			dx2, dy2 := DeltaXY(p.X, p.Y, float64(w.Level.ExitX+1), float64(w.Level.ExitY))
+			if (dx1 <= exitThreshold && dy1 <= exitThreshold) || (dx2 <= exitThreshold && dy2 <= exitThreshold) {
+				w.completeLevel()
+				err := w.NextLevel()
+				if err != nil {
+					return err
+				}
+				break
+			}
+		}
+	}
+
+	w.Clock.Advance()
+	return nil
+}
+
+func (w *World) resetExpiredTimers() {
+	for _, p := range w.Players {
+		if !p.GarlicUntil.IsZero() && w.Clock.Until(p.GarlicUntil) <= 0 {
+			p.GarlicUntil = time.Time{}
+		}
+		if !p.HolyWaterUntil.IsZero() && w.Clock.Until(p.HolyWaterUntil) <= 0 {
+			p.HolyWaterUntil = time.Time{}
+		}
+	}
+}
+
+// HurtCreep deals damage to a creep on behalf of a player, who is awarded
+// points when the creep is killed. The player may be nil. A damage value of -1
+// removes the creep without it being killed by a player.
+func (w *World) HurtCreep(c *Creep, damage int, p *Player) {
+	if damage == -1 {
+		c.Health = 0
+		c.leaveSwarm()
+		if c.CreepType == TypeBat {
+			c.hurtBat()
+		}
+		return
+	}
+
+	c.Health -= damage
+	if c.Health < 0 {
+		c.Health = 0
+	}
+	if c.CreepType == TypeBat {
+		c.hurtBat()
+	}
+	if c.Health > 0 {
+		return
+	}
+	c.leaveSwarm()
+
+	// Killed creep.
+	if p != nil {
+		p.Score += c.Definition().Score * w.LevelNum
+		w.director.LastKill = w.Clock.Now()
+	}
+
+	w.addEvent(Event{EventType: EventCreepKilled, X: c.X, Y: c.Y, Creep: c})
+
+	if c == w.Level.Boss {
+		w.CheckLevelComplete()
+	}
+
+	if c.CreepType == TypeTorch {
+		// TODO play break sound
+		c.Frames = 1
+		c.Frame = 0
+		w.Level.BakePartialLightmap(int(c.X), int(c.Y))
+		return
+	}
+
+	w.addBloodSplatter(c.X, c.Y)
+
+	if !c.Definition().DropsSoul {
+		return
+	}
+
+	soul := w.Level.AddCreep(TypeSoul)
+	w.Level.moveCreep(soul, c.X, c.Y)
+	soul.moveX, soul.moveY = c.moveX/4, c.moveY/4
+	soul.tick, soul.nextAction = c.tick, c.nextAction
+}
+
+// vulnerable returns whether a player is able to be hurt by creeps. Players
+// are not hurt in god mode or while they repel creeps.
+func (w *World) vulnerable(p *Player) bool {
+	return !w.GodMode && !p.repels()
+}
+
+// hurtPlayer deals damage to a player attacked by a creep, either by being
+// bitten or by a projectile fired by the creep. The creep may be nil.
+func (w *World) hurtPlayer(p *Player, c *Creep) {
+	if !w.vulnerable(p) {
+		return
+	}
+
+	p.Health--
+	w.director.Damage++
+
+	w.addEvent(Event{EventType: EventPlayerHurt, X: p.X, Y: p.Y, Creep: c, Player: p})
+
+	w.addBloodSplatter(p.X, p.Y)
+
+	if p.Health <= 0 {
+		w.addEvent(Event{EventType: EventPlayerDied, X: p.X, Y: p.Y, Creep: c, Player: p})
+	}
+}
+
+func (w *World) addBloodSplatter(x, y float64) {
+	t := w.Level.Tile(int(x), int(y))
+	if t != nil {
+		t.Blood = append(t.Blood, w.rng.Int63())
+	}
+}
+
+// DeltaXY returns the absolute distance between two points on each axis.
+func DeltaXY(x1, y1, x2, y2 float64) (dx float64, dy float64) {
+	dx, dy = x1-x2, y1-y2
+	if dx < 0 {
+		dx *= -1
+	}
+	if dy < 0 {
+		dy *= -1
+	}
+	return dx, dy
+}
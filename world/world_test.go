@@ -0,0 +1,1043 @@
+package world
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func newTestWorld(t *testing.T) *World {
+	w, err := NewWorld()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = w.Reset(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Remove creeps so the player is not bitten.
+	w.Level.Creeps = nil
+	w.Level.grid = newSpatialHash(w.Level.W, w.Level.H)
+	for _, item := range w.Level.Items {
+		w.Level.grid.addItem(item)
+	}
+	return w
+}
+
+func TestGarlicPickup(t *testing.T) {
+	w := newTestWorld(t)
+
+	var garlic *Item
+	for _, item := range w.Level.Items {
+		if item.ItemType == ItemTypeGarlic {
+			garlic = item
+			break
+		}
+	}
+	if garlic == nil {
+		t.Fatal("no garlic was spawned")
+	}
+	p := w.Players[0]
+	p.X, p.Y = garlic.X, garlic.Y
+
+	err := w.Step([]Input{{Angle: p.Angle}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if garlic.Health != 0 {
+		t.Error("garlic was not picked up")
+	}
+	if p.GarlicUntil.IsZero() {
+		t.Error("garlicUntil was not set")
+	} else if remaining := w.Clock.Until(p.GarlicUntil); remaining != garlicActiveTime-TickDuration {
+		t.Errorf("unexpected garlic time remaining: expected %s, got %s", garlicActiveTime-TickDuration, remaining)
+	}
+}
+
+func TestRescueSoulsOpensExit(t *testing.T) {
+	w := newTestWorld(t)
+
+	w.SoulsRescued = w.Level.RequiredSouls - 1
+
+	p := w.Players[0]
+	soul := w.Level.AddCreep(TypeSoul)
+	soul.X, soul.Y = p.X, p.Y
+
+	err := w.Step([]Input{{Angle: p.Angle}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if w.SoulsRescued != w.Level.RequiredSouls {
+		t.Fatalf("unexpected souls rescued: expected %d, got %d", w.Level.RequiredSouls, w.SoulsRescued)
+	}
+	if w.Level.ExitOpenTime.IsZero() {
+		t.Error("exit was not opened")
+	}
+
+	var exitOpenEvent bool
+	for _, e := range w.Events {
+		if e.EventType == EventExitOpen {
+			exitOpenEvent = true
+		}
+	}
+	if !exitOpenEvent {
+		t.Error("no exit open event")
+	}
+}
+
+func TestSeededLevel(t *testing.T) {
+	a, b := newTestWorld(t), newTestWorld(t)
+
+	if a.Level.EnterX != b.Level.EnterX || a.Level.EnterY != b.Level.EnterY || a.Level.ExitX != b.Level.ExitX || a.Level.ExitY != b.Level.ExitY {
+		t.Fatal("levels generated using the same seed differ")
+	}
+	for y := 0; y < a.Level.H; y++ {
+		for x := 0; x < a.Level.W; x++ {
+			if a.Level.Tiles[y][x].Floor != b.Level.Tiles[y][x].Floor {
+				t.Fatalf("levels generated using the same seed differ at %d,%d", x, y)
+			}
+		}
+	}
+}
+
+func TestLevelGenerators(t *testing.T) {
+	for _, name := range LevelGenerators {
+		w, err := NewWorld()
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Generator = name
+		for seed := int64(1); seed <= 3; seed++ {
+			err = w.Reset(seed)
+			if err != nil {
+				t.Fatalf("%s: %s", name, err)
+			}
+			l := w.Level
+			if !l.Tile(l.EnterX, l.EnterY-1).Floor || !l.Tile(l.ExitX, l.ExitY+1).Floor {
+				t.Fatalf("%s: entrance or exit does not lead to floor", name)
+			} else if !l.IsFloor(w.Players[0].X, w.Players[0].Y) {
+				t.Fatalf("%s: player is not positioned on floor", name)
+			}
+		}
+	}
+}
+
+func TestReplay(t *testing.T) {
+	record := func(w *World, replay *Replay) {
+		rng := rand.New(rand.NewSource(2))
+		for i := 0; i < TPS*30; i++ {
+			in := Input{
+				MoveX: 1 - rng.Float64()*2,
+				MoveY: 1 - rng.Float64()*2,
+				Angle: rng.Float64() * 2 * math.Pi,
+				Fire:  rng.Intn(2) == 0,
+			}.Quantize()
+			if i == TPS {
+				in.Cheat = CheatGodMode
+			}
+			inputs := []Input{in}
+			if i >= TPS*10 {
+				// A second player joins.
+				inputs = append(inputs, Input{MoveX: -in.MoveX, MoveY: -in.MoveY, Angle: -in.Angle, Fire: true}.Quantize())
+			}
+			replay.Record(inputs)
+
+			err := w.Step(inputs)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	a, err := NewWorld()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = a.Reset(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay := NewReplay(a)
+	record(a, replay)
+
+	buf := &bytes.Buffer{}
+	err = replay.Write(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replay, err = ReadReplay(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := NewWorld()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.Reset(replay.Seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, inputs := range replay.Inputs {
+		err = b.Step(inputs)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(a.Players) != 2 || len(b.Players) != 2 {
+		t.Fatalf("unexpected number of players: expected 2, got %d and %d", len(a.Players), len(b.Players))
+	}
+	for i := range a.Players {
+		pa, pb := a.Players[i], b.Players[i]
+		if pa.X != pb.X || pa.Y != pb.Y {
+			t.Errorf("unexpected player %d position: expected %f,%f, got %f,%f", i+1, pa.X, pa.Y, pb.X, pb.Y)
+		}
+		if pa.Score != pb.Score || pa.Health != pb.Health {
+			t.Errorf("unexpected player %d state: expected %+v, got %+v", i+1, pa, pb)
+		}
+	}
+	if a.SoulsRescued != b.SoulsRescued {
+		t.Errorf("unexpected souls rescued: expected %d, got %d", a.SoulsRescued, b.SoulsRescued)
+	}
+	if a.GodMode != b.GodMode || !b.GodMode {
+		t.Error("cheat was not replayed")
+	}
+}
+
+func TestSaveLoad(t *testing.T) {
+	a := newTestWorld(t)
+	for i := 0; i < 20; i++ {
+		a.Level.AddCreep(TypeVampire)
+		a.Level.AddCreep(TypeBat)
+	}
+
+	steps := func(w *World, n int) {
+		for i := 0; i < n; i++ {
+			err := w.Step([]Input{{MoveX: 1, Angle: float64(i) / 100, Fire: true}, {MoveY: 1, Angle: float64(-i) / 100, Fire: true}})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	steps(a, TPS)
+
+	buf := &bytes.Buffer{}
+	err := a.Save(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := NewWorld()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = b.Load(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps(a, TPS*10)
+	steps(b, TPS*10)
+
+	for i := range a.Players {
+		pa, pb := a.Players[i], b.Players[i]
+		if pa.X != pb.X || pa.Y != pb.Y || pa.Score != pb.Score || pa.Health != pb.Health {
+			t.Errorf("unexpected player %d state: expected %+v, got %+v", i+1, pa, pb)
+		}
+	}
+	if len(a.Level.Creeps) != len(b.Level.Creeps) {
+		t.Fatalf("unexpected number of creeps: expected %d, got %d", len(a.Level.Creeps), len(b.Level.Creeps))
+	}
+	for i := range a.Level.Creeps {
+		ca, cb := a.Level.Creeps[i], b.Level.Creeps[i]
+		if ca.X != cb.X || ca.Y != cb.Y || ca.Health != cb.Health {
+			t.Fatalf("unexpected creep state: expected %f,%f (%d), got %f,%f (%d)", ca.X, ca.Y, ca.Health, cb.X, cb.Y, cb.Health)
+		}
+	}
+}
+
+func TestLoadInvalid(t *testing.T) {
+	a := newTestWorld(t)
+	a.Level.AddCreep(TypeVampire)
+
+	buf := &bytes.Buffer{}
+	err := a.Save(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := gzip.NewReader(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, corrupt := range map[string]func(s *saveFile){
+		"creep type": func(s *saveFile) { s.Level.Creeps[0].CreepType = len(CreepDefinitions) },
+		"player":     func(s *saveFile) { s.Level.Creeps[0].Player = -1 },
+		"size":       func(s *saveFile) { s.Level.W, s.Level.H, s.Level.Tiles = 0, 0, nil },
+	} {
+		s := &saveFile{}
+		err = json.Unmarshal(data, s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		corrupt(s)
+
+		buf.Reset()
+		zw := gzip.NewWriter(buf)
+		err = json.NewEncoder(zw).Encode(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = zw.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		b, err := NewWorld()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if b.Load(buf) == nil {
+			t.Errorf("save file with invalid %s was loaded", name)
+		}
+	}
+}
+
+func TestCoop(t *testing.T) {
+	w := newTestWorld(t)
+
+	err := w.Step([]Input{{}, {}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(w.Players) != 2 {
+		t.Fatalf("unexpected number of players: expected 2, got %d", len(w.Players))
+	}
+	a, b := w.Players[0], w.Players[1]
+	b.X += 5
+
+	c := w.Level.AddCreep(TypeVampire)
+	c.X, c.Y = b.X+1, b.Y
+	c.Update()
+	if c.player != b {
+		t.Error("creep does not target the nearest player")
+	}
+
+	a.Health = 0
+	c.X, c.Y = a.X, a.Y
+	c.Update()
+	if c.player != b {
+		t.Error("creep targets a dead player")
+	}
+
+	err = w.Step([]Input{{}, {}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !w.Alive() {
+		t.Error("game ended while a player is alive")
+	}
+
+	b.Health = 0
+	if w.Alive() {
+		t.Error("game continues after all players died")
+	}
+}
+
+func TestPathTarget(t *testing.T) {
+	// A wall separates the creep from the player, with a gap at the bottom.
+	layout := []string{
+		"#######",
+		"#..#..#",
+		"#..#..#",
+		"#.....#",
+		"#######",
+	}
+	l := &Level{
+		W: len(layout[0]),
+		H: len(layout),
+	}
+	l.Tiles = make([][]*Tile, l.H)
+	for y, row := range layout {
+		l.Tiles[y] = make([]*Tile, l.W)
+		for x, r := range row {
+			l.Tiles[y][x] = &Tile{Floor: r == '.'}
+		}
+	}
+	l.Players = []*Player{{X: 5, Y: 1, Health: 1}}
+	l.updateFlowField()
+
+	x, y := 1.0, 1.0
+	for i := 0; i < 10; i++ {
+		tx, ty, ok := l.PathTarget(x, y)
+		if !ok {
+			break
+		}
+		if !l.IsFloor(tx, ty) {
+			t.Fatalf("path leads into a wall at %f,%f", tx, ty)
+		}
+		x, y = tx, ty
+	}
+	if x != 5 || y != 1 {
+		t.Errorf("path does not reach the player: ended at %f,%f", x, y)
+	}
+}
+
+func TestSpatialHash(t *testing.T) {
+	w := newTestWorld(t)
+	l := w.Level
+
+	a := l.AddCreep(TypeVampire)
+	b := l.AddCreep(TypeVampire)
+	l.moveCreep(b, 10, 10)
+	l.moveCreep(a, 11, 10)
+
+	found := l.grid.queryCreeps(10, 10, 2, nil)
+	if len(found) != 2 || found[0] != a || found[1] != b {
+		t.Fatalf("unexpected creeps near 10,10: %v", found)
+	}
+
+	l.moveCreep(a, 50, 50)
+	found = l.grid.queryCreeps(10, 10, 2, nil)
+	if len(found) != 1 || found[0] != b {
+		t.Errorf("creep was not moved to a different cell: %v", found)
+	}
+	found = l.grid.queryCreeps(50, 50, 0, nil)
+	if len(found) != 1 || found[0] != a {
+		t.Errorf("creep was not found in its new cell: %v", found)
+	}
+}
+
+func TestFlocking(t *testing.T) {
+	w := newTestWorld(t)
+	l := w.Level
+
+	a := l.AddCreep(TypeVampire)
+	b := l.AddCreep(TypeVampire)
+	l.moveCreep(a, 10, 10)
+	l.moveCreep(b, 10.25, 10)
+	a.moveX, a.moveY, b.moveX, b.moveY = 0, 0, 0, 0
+
+	ax, _ := a.flock()
+	bx, _ := b.flock()
+	if ax >= 0 || bx <= 0 {
+		t.Errorf("overlapping creeps were not separated: %f, %f", ax, bx)
+	}
+
+	swarm := l.AddSwarm(minSwarmSize)
+	leader := swarm[0]
+	if leader.Followers != minSwarmSize-1 {
+		t.Fatalf("expected %d followers, got %d", minSwarmSize-1, leader.Followers)
+	}
+	for _, c := range swarm[1:] {
+		if !c.following() {
+			t.Fatal("bat is not following the swarm leader")
+		}
+	}
+
+	w.HurtCreep(swarm[1], -1, nil)
+	if leader.Followers != minSwarmSize-2 {
+		t.Errorf("expected %d followers, got %d", minSwarmSize-2, leader.Followers)
+	}
+	w.HurtCreep(leader, -1, nil)
+	if swarm[2].following() {
+		t.Error("bat is following a dead swarm leader")
+	}
+}
+
+func TestBatStates(t *testing.T) {
+	w := newTestWorld(t)
+	p := w.Players[0]
+	p.Weapon = nil
+
+	c := w.Level.AddCreep(TypeBat)
+	if c.State != BatRoosting {
+		t.Fatalf("expected new bat to roost, got state %d", c.State)
+	}
+
+	w.HurtCreep(c, 1, p)
+	if c.State != BatHit {
+		t.Fatalf("expected hurt bat to be hit, got state %d", c.State)
+	}
+
+	w.HurtCreep(c, 1, p)
+	if !c.Dying() {
+		t.Fatalf("expected killed bat to be dying, got state %d", c.State)
+	}
+
+	for i := 0; i < TPS*2; i++ {
+		err := w.Step([]Input{{Angle: p.Angle}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if c.State != BatDead {
+		t.Errorf("expected bat to finish dying, got state %d", c.State)
+	}
+	for _, creep := range w.Level.Creeps {
+		if creep == c {
+			t.Error("dead bat was not removed")
+		}
+	}
+}
+
+func TestAngleDifference(t *testing.T) {
+	for _, test := range []struct {
+		a, b, expected float64
+	}{
+		{0, 0, 0},
+		{math.Pi / 2, 0, math.Pi / 2},
+		{0, math.Pi / 2, math.Pi / 2},
+		{math.Pi, -math.Pi, 0},
+		{math.Pi - 0.1, -math.Pi + 0.1, 0.2},
+		{-math.Pi / 2, math.Pi / 2, math.Pi},
+		{5 * math.Pi / 2, 0, math.Pi / 2},
+		{-7 * math.Pi / 4, math.Pi / 4, 0},
+	} {
+		if d := angleDifference(test.a, test.b); math.Abs(d-test.expected) > 1e-9 {
+			t.Errorf("angle difference between %f and %f: expected %f, got %f", test.a, test.b, test.expected, d)
+		}
+	}
+}
+
+func TestInViewCone(t *testing.T) {
+	for _, test := range []struct {
+		angle, x, y float64
+		expected    bool
+	}{
+		{0, 5, 0, true},             // Ahead
+		{0, -5, 0, false},           // Behind
+		{0, 5, 4, true},             // Ahead and to the side
+		{0, 5, 6, false},            // Beside
+		{math.Pi / 2, 0, 5, true},   // Below
+		{math.Pi / 2, 0, -5, false}, // Above
+		{math.Pi, -5, 0.1, true},    // Ahead across the angle wrap
+		{-math.Pi, -5, -0.1, true},  // Ahead across the angle wrap
+	} {
+		if inViewCone(0, 0, test.angle, test.x, test.y, ghostViewCone) != test.expected {
+			t.Errorf("target at %f,%f facing %f: expected in view %t", test.x, test.y, test.angle, test.expected)
+		}
+	}
+}
+
+func TestGhostObserved(t *testing.T) {
+	w := newTestWorld(t)
+	p := w.Players[0]
+	p.Weapon = nil
+	w.GodMode = true
+
+	c := w.Level.AddCreep(TypeGhost)
+	w.Level.moveCreep(c, p.X+3, p.Y)
+
+	// Watch the ghost.
+	for i := 0; i < TPS; i++ {
+		err := w.Step([]Input{{Angle: 0}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if c.X != p.X+3 || c.Y != p.Y {
+		t.Fatalf("observed ghost moved to %f,%f", c.X-p.X, c.Y-p.Y)
+	}
+
+	// Look away from the ghost.
+	for i := 0; i < TPS/4; i++ {
+		err := w.Step([]Input{{Angle: math.Pi}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if dx, _ := DeltaXY(c.X, c.Y, p.X, p.Y); dx >= 3 {
+		t.Errorf("unobserved ghost did not advance: %f", dx)
+	}
+}
+
+func TestCreepDefinitions(t *testing.T) {
+	for creepType, name := range map[int]string{
+		TypeVampire: "vampire",
+		TypeBat:     "bat",
+		TypeGhost:   "ghost",
+		TypeSoul:    "soul",
+		TypeTorch:   "torch",
+
+		TypeVampireLord: "vampirelord",
+		TypeBloodMage:   "bloodmage",
+	} {
+		if def := CreepDefinitions[creepType]; def.Name != name {
+			t.Errorf("expected creep type %d to be %s, got %s", creepType, name, def.Name)
+		}
+	}
+
+	invalid := *CreepDefinitions[TypeVampire]
+	invalid.ActionRange = 0
+	if invalid.validate() == nil {
+		t.Error("creep without action range is valid")
+	}
+	invalid = *CreepDefinitions[TypeVampire]
+	invalid.Health = -1
+	if invalid.validate() == nil {
+		t.Error("creep with negative health is valid")
+	}
+}
+
+func TestBoss(t *testing.T) {
+	w := newTestWorld(t)
+	err := w.Warp(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := w.Players[0]
+
+	boss := w.Level.Boss
+	if boss == nil {
+		t.Fatal("final level has no boss")
+	}
+	if !w.Level.IsFloor(boss.X, boss.Y) {
+		t.Errorf("boss is not on the floor at %f,%f", boss.X, boss.Y)
+	}
+
+	maxHealth := boss.Definition().Health
+	for _, test := range []struct {
+		health, phase int
+	}{
+		{maxHealth, 1},
+		{maxHealth * 2 / 3, 2},
+		{maxHealth / 3, 3},
+		{1, 3},
+	} {
+		boss.Health = test.health
+		if phase := boss.BossPhase(); phase != test.phase {
+			t.Errorf("expected phase %d at %d health, got %d", test.phase, test.health, phase)
+		}
+	}
+	boss.Health = maxHealth
+
+	w.SoulsRescued = w.Level.RequiredSouls
+	w.CheckLevelComplete()
+	if !w.Level.ExitOpenTime.IsZero() {
+		t.Fatal("exit opened before the boss was defeated")
+	}
+
+	w.HurtCreep(boss, maxHealth, p)
+	if w.Level.ExitOpenTime.IsZero() {
+		t.Error("exit did not open after the boss was defeated")
+	}
+}
+
+// newWallLevel returns a small Level of floor divided by a wall at x=4 which
+// has a gap at the bottom.
+func newWallLevel() *Level {
+	l := &Level{W: 9, H: 5}
+	l.Tiles = make([][]*Tile, l.H)
+	for y := range l.Tiles {
+		l.Tiles[y] = make([]*Tile, l.W)
+		for x := range l.Tiles[y] {
+			l.Tiles[y][x] = &Tile{Floor: x != 4 || y == 4}
+		}
+	}
+	return l
+}
+
+func TestRaycast(t *testing.T) {
+	l := newWallLevel()
+
+	x, y, blocked := l.Raycast(1, 1, 7, 1)
+	if !blocked || x != 4 || y != 1 {
+		t.Errorf("expected ray to be blocked at 4,1, got %d,%d (%t)", x, y, blocked)
+	}
+	x, y, blocked = l.Raycast(7, 2.2, 1, 0.8)
+	if !blocked || x != 4 {
+		t.Errorf("expected ray to be blocked at x 4, got %d,%d (%t)", x, y, blocked)
+	}
+	if !l.LineOfSight(1, 4, 7, 4) {
+		t.Error("expected line of sight through the gap in the wall")
+	}
+	if !l.LineOfSight(1, 1, 3, 3) {
+		t.Error("expected line of sight beside the wall")
+	}
+	if l.LineOfSight(1, 1, 1, 6) {
+		t.Error("expected no line of sight outside of the level")
+	}
+}
+
+func TestAwareness(t *testing.T) {
+	l := newWallLevel()
+	p := &Player{X: 3, Y: 1, HasTorch: true, Health: 1}
+	l.Players = []*Player{p}
+
+	newCreep := func(x, y float64) *Creep {
+		return &Creep{
+			X:         x,
+			Y:         y,
+			CreepType: TypeVampire,
+			Health:    1,
+			level:     l,
+			player:    p,
+			rng:       rand.New(rand.NewSource(1)),
+		}
+	}
+
+	near := newCreep(1, 1)
+	near.updateAwareness(false)
+	if near.Awareness != AwarenessHunting {
+		t.Errorf("expected creep in sight to hunt, got %d", near.Awareness)
+	}
+
+	hidden := newCreep(5, 1)
+	hidden.updateAwareness(false)
+	if hidden.Awareness != AwarenessUnaware {
+		t.Errorf("expected creep behind a wall to be unaware, got %d", hidden.Awareness)
+	}
+
+	hidden.alert(p.X, p.Y)
+	if !hidden.updateAwareness(false) || hidden.Awareness != AwarenessSuspicious {
+		t.Errorf("expected alerted creep to investigate, got %d", hidden.Awareness)
+	}
+	for i := 0; i <= suspicionMemory; i++ {
+		hidden.updateAwareness(true)
+	}
+	if hidden.Awareness != AwarenessUnaware {
+		t.Errorf("expected creep to forget the player, got %d", hidden.Awareness)
+	}
+}
+
+func TestHitReaction(t *testing.T) {
+	w := newTestWorld(t)
+	p := w.Players[0]
+	p.Weapon = nil
+
+	c := w.Level.AddCreep(TypeBat)
+	w.Level.moveCreep(c, p.X, p.Y)
+	health := p.Health
+
+	w.shootCreep(c, &Projectile{X: c.X, Y: c.Y, Angle: 0, Player: p})
+	if !c.Staggered() || c.LastHit != w.Clock.Now() {
+		t.Fatal("creep did not react to being hit")
+	}
+	var hit bool
+	for _, e := range w.Events {
+		hit = hit || (e.EventType == EventCreepHit && e.Creep == c)
+	}
+	if !hit {
+		t.Error("no hit event")
+	}
+
+	def := c.Definition()
+	for i := 0; i < def.StaggerTicks; i++ {
+		err := w.Step([]Input{{Angle: p.Angle}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !w.Level.IsFloor(c.X, c.Y) {
+			t.Fatalf("creep was knocked back into a wall at %f,%f", c.X, c.Y)
+		}
+	}
+	if c.Staggered() {
+		t.Error("creep did not recover from being hit")
+	}
+	if p.Health != health {
+		t.Error("staggered creep bit the player")
+	}
+}
+
+func TestSpawnSchedule(t *testing.T) {
+	prev := spawnSchedule(LevelDefinitions[0], DifficultyNormal)
+	for i, def := range LevelDefinitions[1:] {
+		cfg := spawnSchedule(def, DifficultyNormal)
+		if cfg.StartingCreeps <= prev.StartingCreeps || cfg.MaxCreeps <= prev.MaxCreeps || cfg.MaxGhosts <= prev.MaxGhosts {
+			t.Errorf("level %d is not harder than level %d: %+v, %+v", i+2, i+1, cfg, prev)
+		}
+		prev = cfg
+	}
+
+	easy, hard := spawnSchedule(LevelDefinitions[1], DifficultyEasy), spawnSchedule(LevelDefinitions[1], DifficultyHard)
+	if easy.MaxCreeps >= hard.MaxCreeps || easy.WaveInterval <= hard.WaveInterval {
+		t.Errorf("easy difficulty is not easier than hard difficulty: %+v, %+v", easy, hard)
+	}
+}
+
+func TestDirector(t *testing.T) {
+	w := newTestWorld(t)
+	w.GodMode = true
+	p := w.Players[0]
+
+	// Keep players under pressure.
+	w.director.Damage = pressureDamage * 10
+	for i := 0; i < TPS*10; i++ {
+		err := w.Step([]Input{{Angle: p.Angle}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(w.Level.Creeps) != 0 {
+		t.Fatalf("expected no waves while under pressure, got %d creeps", len(w.Level.Creeps))
+	}
+
+	// Relieve the pressure.
+	w.director.Damage = 0
+	w.director.NextWave = 1
+	err := w.Step([]Input{{Angle: p.Angle}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(w.Level.Creeps) == 0 {
+		t.Fatal("expected a wave once no longer under pressure")
+	}
+	for _, c := range w.Level.Creeps {
+		dx, dy := c.X-p.X, c.Y-p.Y
+		if math.Sqrt(dx*dx+dy*dy) < waveSpawnMin-3 {
+			t.Errorf("creep spawned in sight of the player at %f,%f", dx, dy)
+		}
+	}
+}
+
+func TestRangedCreep(t *testing.T) {
+	l := newWallLevel()
+	p := &Player{X: 1, Y: 1, HasTorch: true, Health: 1}
+	l.Players = []*Player{p}
+	w := &World{Level: l, Players: l.Players, Clock: NewClock()}
+
+	c := &Creep{
+		X:         3,
+		Y:         2,
+		CreepType: TypeBloodMage,
+		Health:    1,
+		level:     l,
+		player:    p,
+		rng:       rand.New(rand.NewSource(1)),
+		Awareness: AwarenessHunting,
+	}
+
+	c.keepDistance()
+	if c.moveX <= 0 || c.moveY <= 0 {
+		t.Errorf("expected blood mage to back away, got %f,%f", c.moveX, c.moveY)
+	}
+
+	w.creepFire(c)
+	w.creepFire(c)
+	if len(w.Projectiles) != 1 {
+		t.Fatalf("expected 1 projectile, got %d", len(w.Projectiles))
+	}
+	if proj := w.Projectiles[0]; proj.Faction != FactionCreeps || proj.Creep != c {
+		t.Errorf("unexpected projectile owner: faction %d, creep %v", proj.Faction, proj.Creep)
+	}
+
+	for _, godMode := range []bool{true, false} {
+		w := newTestWorld(t)
+		w.GodMode = godMode
+		p := w.Players[0]
+		w.Projectiles = []*Projectile{{X: p.X, Y: p.Y, Speed: 0.001, Faction: FactionCreeps}}
+
+		err := w.Step([]Input{{Angle: p.Angle}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(w.Projectiles) != 0 {
+			t.Errorf("projectile was not removed after hitting the player (god mode %t)", godMode)
+		}
+		expected := StartingHealth - 1
+		if godMode {
+			expected = StartingHealth
+		}
+		if p.Health != expected {
+			t.Errorf("expected health %d (god mode %t), got %d", expected, godMode, p.Health)
+		}
+	}
+}
+
+func TestSimulationLevels(t *testing.T) {
+	w := newTestWorld(t)
+	p := w.Players[0]
+
+	newCreep := func(distance float64) *Creep {
+		c := w.Level.AddCreep(TypeVampire)
+		w.Level.moveCreep(c, p.X+distance, p.Y)
+		return c
+	}
+	near, far, asleep := newCreep(5), newCreep(DefaultSimulationRadius+8), newCreep(DefaultSleepRadius+8)
+
+	for _, test := range []struct {
+		c        *Creep
+		expected int
+	}{
+		{near, SimulationFull},
+		{far, SimulationFar},
+		{asleep, SimulationAsleep},
+	} {
+		if level := w.simulationLevel(test.c); level != test.expected {
+			t.Errorf("expected creep at %f to be simulated at level %d, got %d", test.c.X-p.X, test.expected, level)
+		}
+	}
+
+	x, y := asleep.X, asleep.Y
+	for i := 0; i < farUpdateTicks*2; i++ {
+		err := w.Step([]Input{{Angle: p.Angle}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if asleep.X != x || asleep.Y != y {
+		t.Errorf("sleeping creep moved from %f,%f to %f,%f", x, y, asleep.X, asleep.Y)
+	}
+
+	// Wake the creep with a noise.
+	asleep.alert(p.X, p.Y)
+	if level := w.simulationLevel(asleep); level != SimulationFar {
+		t.Errorf("expected alerted creep to wake, got level %d", level)
+	}
+}
+
+func TestFeeding(t *testing.T) {
+	w := newTestWorld(t)
+	p := w.Players[0]
+
+	c := w.Level.AddCreep(TypeVampire)
+	w.Level.moveCreep(c, p.X, p.Y)
+	soul := w.Level.AddCreep(TypeSoul)
+	w.Level.moveCreep(soul, p.X+0.5, p.Y)
+	speed := c.moveSpeed()
+
+	for i := 0; i < soulSearchTicks && !c.seekSoul(); i++ {
+		w.Clock.Advance()
+	}
+	if c.prey != soul {
+		t.Fatal("vampire did not seek the soul")
+	}
+
+	w.feed(c)
+	if soul.Health != 0 {
+		t.Error("soul was not eaten")
+	}
+	if c.SoulsEaten != 1 || c.Health != 2 || c.moveSpeed() <= speed {
+		t.Errorf("vampire did not grow stronger: %d souls, %d health, speed %f", c.SoulsEaten, c.Health, c.moveSpeed())
+	}
+
+	w.completeLevel()
+	if stats := w.CompletedLevels[0]; stats.SoulsEaten != 1 {
+		t.Errorf("expected 1 soul eaten in level stats, got %d", stats.SoulsEaten)
+	}
+}
+
+func TestTiledMap(t *testing.T) {
+	const tiledJSON = `{
+		"width": 4, "height": 5, "tilewidth": 32, "tileheight": 32,
+		"orientation": "orthogonal", "infinite": false,
+		"tilesets": [{"firstgid": 1, "columns": 9, "image": "Tiles-Sandstone-Dungeons.png"}],
+		"layers": [
+			{"type": "tilelayer", "name": "floor", "data": [0,0,0,0, 0,0,0,0, 0,0,0,0, 0,13,13,0, 0,0,0,0]},
+			{"type": "tilelayer", "name": "topwalls", "data": [0,0,0,0, 0,0,0,0, 0,20,20,0, 0,0,0,0, 0,0,0,0]},
+			{"type": "objectgroup", "objects": [
+				{"type": "entrance", "x": 48, "y": 144},
+				{"type": "exit", "x": 48, "y": 80},
+				{"type": "creep", "name": "vampire", "x": 80, "y": 112, "properties": [{"name": "count", "type": "int", "value": 2}]}
+			]}
+		]
+	}`
+	m, err := ReadTiledJSON(strings.NewReader(tiledJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := NewTiledLevel(1, m, nil, rand.New(rand.NewSource(1)), NewClock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !l.Tiles[3][1].Floor || !l.Tiles[2][1].Wall || l.TopWalls[2][2] == nil || l.Tiles[0][0].Floor {
+		t.Error("tile flags do not match map layers")
+	}
+	if l.EnterX != 1 || l.EnterY != 4 || l.ExitX != 1 || l.ExitY != 2 {
+		t.Errorf("unexpected entrance %d,%d or exit %d,%d", l.EnterX, l.EnterY, l.ExitX, l.ExitY)
+	}
+	if len(l.Creeps) != 2 || l.Creeps[0].X != 2 || l.Creeps[0].Y != 3 {
+		t.Error("creeps were not spawned at their object")
+	}
+
+	LevelDefinitions = append(LevelDefinitions, &LevelDefinition{
+		Name:          "Arena",
+		Map:           "arena.tmx",
+		RequiredSouls: 1,
+		Spawn:         LevelDefinitions[0].Spawn,
+	})
+	defer func() {
+		LevelDefinitions = LevelDefinitions[:len(LevelDefinitions)-1]
+	}()
+
+	w := newTestWorld(t)
+	err = w.Warp(len(LevelDefinitions))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, p := w.Level, w.Players[0]
+	if l.W != 20 || l.H != 18 || l.Boss == nil || len(l.Torches) != 4 || len(l.Items) != 2 {
+		t.Fatalf("arena was not built from its map: %dx%d, boss %v, %d torches, %d items", l.W, l.H, l.Boss != nil, len(l.Torches), len(l.Items))
+	} else if p.X != float64(l.EnterX)+0.5 || !l.IsFloor(p.X, p.Y-1) {
+		t.Fatal("player did not enter the arena through its entrance")
+	}
+	for i := 0; i < TPS; i++ {
+		err = w.Step(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLevelDefinitions(t *testing.T) {
+	extra := *LevelDefinitions[len(LevelDefinitions)-1]
+	extra.Name = "Beyond"
+	extra.Generator = GeneratorCave
+	extra.Size = 128
+	extra.Boss = ""
+	LevelDefinitions = append(LevelDefinitions, &extra)
+	defer func() {
+		LevelDefinitions = LevelDefinitions[:len(LevelDefinitions)-1]
+	}()
+
+	for levelNum, def := range LevelDefinitions {
+		levelNum++
+		err := def.validate()
+		if err != nil {
+			t.Fatalf("level %d %s", levelNum, err)
+		}
+
+		w := newTestWorld(t)
+		if levelNum > 1 {
+			err = w.Warp(levelNum)
+			if err != nil {
+				t.Fatalf("level %d: %s", levelNum, err)
+			}
+		}
+		if w.LevelNum != levelNum || w.Level.W != def.Size {
+			t.Fatalf("level %d was not generated", levelNum)
+		}
+		if def.Boss != "" && (w.Level.Boss == nil || w.Level.Boss.Definition().Name != def.Boss) {
+			t.Fatalf("level %d is not guarded by %s", levelNum, def.Boss)
+		}
+		for i := 0; i < 300; i++ {
+			err = w.Step(nil)
+			if err != nil {
+				t.Fatalf("level %d: %s", levelNum, err)
+			}
+		}
+		if w.Clock.Tick() < 300 {
+			t.Fatalf("level %d was not simulated", levelNum)
+		}
+	}
+
+	invalid := extra
+	invalid.Spawn.WaveGrowth = 0
+	if invalid.validate() == nil {
+		t.Error("level without wave growth is valid")
+	}
+}